@@ -0,0 +1,204 @@
+package redmap
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Codec converts values of a specific type to and from strings, overriding any other conversion
+// that Marshal and Unmarshal would perform on that type. Create one with NewCodec.
+type Codec struct {
+	typ    reflect.Type
+	encode encodeFunc
+	decode decodeFunc
+}
+
+type (
+	// encodeFunc encodes a value of a specific type into a string.
+	encodeFunc func(reflect.Value) (string, error)
+	// decodeFunc decodes a string into a value of a specific type.
+	decodeFunc func(string) (reflect.Value, error)
+)
+
+// NewCodec returns a Codec for type T. Either function may be nil, in which case
+// the conversion in that direction falls back to the ones documented in Marshal and Unmarshal.
+//
+// NewCodec panics if T is a pointer type, because Marshal and Unmarshal dereference pointers
+// before looking up codecs: a codec for T also converts fields of type *T, so register that instead.
+func NewCodec[T any](encode func(T) (string, error), decode func(string) (T, error)) Codec {
+	codec := Codec{typ: reflect.TypeOf(new(T)).Elem()}
+	if codec.typ.Kind() == reflect.Ptr {
+		panic(fmt.Sprintf("redmap: codec type %s is a pointer", codec.typ))
+	}
+	if encode != nil {
+		codec.encode = func(val reflect.Value) (string, error) {
+			v, _ := val.Interface().(T) // A nil interface converts to the zero value.
+			return encode(v)
+		}
+	}
+	if decode != nil {
+		codec.decode = func(str string) (reflect.Value, error) {
+			v, err := decode(str)
+			return reflect.ValueOf(&v).Elem(), err
+		}
+	}
+	return codec
+}
+
+// codecRegistry is a concurrency-safe set of codecs keyed by type.
+type codecRegistry struct {
+	sync.RWMutex
+	m map[reflect.Type]Codec
+}
+
+// globalCodecs holds the codecs registered at package level.
+var globalCodecs = codecRegistry{m: make(map[reflect.Type]Codec)}
+
+// RegisterCodec registers, at package level, the functions converting values of type T
+// to and from strings, as described by NewCodec. Registering a codec for an already
+// registered type replaces it. Codecs set with UseCodec on an Encoder or Decoder take precedence.
+//
+// RegisterCodec is safe for concurrent use, but it is best called during initialization.
+func RegisterCodec[T any](encode func(T) (string, error), decode func(string) (T, error)) {
+	codec := NewCodec(encode, decode)
+	globalCodecs.Lock()
+	defer globalCodecs.Unlock()
+	globalCodecs.m[codec.typ] = codec
+}
+
+// RegisterParser registers parse as the function that Unmarshal uses to decode fields of type T,
+// leaving the encoding untouched. It is mostly useful as the inverse of fmt.Stringer for types
+// that don't implement encoding.TextUnmarshaler, such as enums generated by the stringer tool.
+// Like NewCodec, it panics if T is a pointer type.
+//
+// RegisterParser is safe for concurrent use, but it is best called during initialization.
+func RegisterParser[T any](parse func(string) (T, error)) {
+	parser := NewCodec(nil, parse)
+	globalCodecs.Lock()
+	defer globalCodecs.Unlock()
+	codec := globalCodecs.m[parser.typ]
+	codec.typ, codec.decode = parser.typ, parser.decode
+	globalCodecs.m[codec.typ] = codec
+}
+
+// namedCodecs holds the codecs registered with RegisterNamedCodec.
+var namedCodecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{m: make(map[string]Codec)}
+
+// RegisterNamedCodec registers a codec for type T under name, as described by NewCodec.
+// Unlike codecs registered with RegisterCodec, a named codec is only used for fields requesting
+// it with the `codec` option in their format string, e.g. `redmap:"created,codec=unixms"`.
+// Registering a codec under an already registered name replaces it.
+//
+// The following named codecs for time.Time are registered by default:
+//
+//   - "unix": seconds since the Unix epoch.
+//   - "unixms": milliseconds since the Unix epoch.
+//   - "rfc3339": time.RFC3339 format.
+//   - "rfc3339nano": time.RFC3339Nano format.
+//
+// RegisterNamedCodec is safe for concurrent use, but it is best called during initialization.
+func RegisterNamedCodec[T any](name string, encode func(T) (string, error), decode func(string) (T, error)) {
+	codec := NewCodec(encode, decode)
+	namedCodecs.Lock()
+	defer namedCodecs.Unlock()
+	namedCodecs.m[name] = codec
+}
+
+// namedCodec returns the codec registered under name, checking it converts values of typ.
+func namedCodec(name string, typ reflect.Type) (Codec, error) {
+	namedCodecs.RLock()
+	codec, ok := namedCodecs.m[name]
+	namedCodecs.RUnlock()
+	if !ok {
+		return Codec{}, fmt.Errorf("codec %q is not registered", name)
+	}
+	if codec.typ != typ {
+		return Codec{}, fmt.Errorf("codec %q converts %s, not %s", name, codec.typ, typ)
+	}
+	return codec, nil
+}
+
+func init() {
+	RegisterNamedCodec("unix",
+		func(t time.Time) (string, error) { return strconv.FormatInt(t.Unix(), 10), nil },
+		func(s string) (time.Time, error) {
+			sec, err := strconv.ParseInt(s, 10, 64)
+			return time.Unix(sec, 0), err
+		},
+	)
+	RegisterNamedCodec("unixms",
+		func(t time.Time) (string, error) { return strconv.FormatInt(t.UnixMilli(), 10), nil },
+		func(s string) (time.Time, error) {
+			msec, err := strconv.ParseInt(s, 10, 64)
+			return time.UnixMilli(msec), err
+		},
+	)
+	RegisterNamedCodec("rfc3339",
+		func(t time.Time) (string, error) { return t.Format(time.RFC3339), nil },
+		func(s string) (time.Time, error) { return time.Parse(time.RFC3339, s) },
+	)
+	RegisterNamedCodec("rfc3339nano",
+		func(t time.Time) (string, error) { return t.Format(time.RFC3339Nano), nil },
+		func(s string) (time.Time, error) { return time.Parse(time.RFC3339Nano, s) },
+	)
+}
+
+// UseCodec sets codec to convert values of its type, taking precedence over
+// the codecs registered at package level. Setting a codec for the same type
+// more than once replaces it.
+func (c *config) UseCodec(codec Codec) {
+	if c.codecs == nil {
+		c.codecs = make(map[reflect.Type]Codec)
+	}
+	c.codecs[codec.typ] = codec
+}
+
+// encoderFor returns the encoding function set for typ, if any.
+// If tags requests a named codec, only that codec is considered.
+func (c *config) encoderFor(typ reflect.Type, tags structTags) (encodeFunc, bool, error) {
+	if tags.codec != "" {
+		codec, err := namedCodec(tags.codec, typ)
+		return codec.encode, codec.encode != nil, err
+	}
+	encode, ok := c.typeEncoder(typ)
+	return encode, ok, nil
+}
+
+// decoderFor returns the decoding function set for typ, if any.
+// If tags requests a named codec, only that codec is considered.
+func (c *config) decoderFor(typ reflect.Type, tags structTags) (decodeFunc, bool, error) {
+	if tags.codec != "" {
+		codec, err := namedCodec(tags.codec, typ)
+		return codec.decode, codec.decode != nil, err
+	}
+	decode, ok := c.typeDecoder(typ)
+	return decode, ok, nil
+}
+
+// typeEncoder returns the encoding function set for typ, if any.
+func (c *config) typeEncoder(typ reflect.Type) (encodeFunc, bool) {
+	if codec := c.codecs[typ]; codec.encode != nil {
+		return codec.encode, true
+	}
+	globalCodecs.RLock()
+	defer globalCodecs.RUnlock()
+	codec := globalCodecs.m[typ]
+	return codec.encode, codec.encode != nil
+}
+
+// typeDecoder returns the decoding function set for typ, if any.
+func (c *config) typeDecoder(typ reflect.Type) (decodeFunc, bool) {
+	if codec := c.codecs[typ]; codec.decode != nil {
+		return codec.decode, true
+	}
+	globalCodecs.RLock()
+	defer globalCodecs.RUnlock()
+	codec := globalCodecs.m[typ]
+	return codec.decode, codec.decode != nil
+}
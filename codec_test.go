@@ -0,0 +1,158 @@
+package redmap_test
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livingsilver94/redmap"
+)
+
+// stubOpaque is a type unsupported by redmap's built-in conversions.
+type stubOpaque [2]string
+
+func encodeStubOpaque(v stubOpaque) (string, error) { return v[0] + "|" + v[1], nil }
+
+func decodeStubOpaque(str string) (stubOpaque, error) {
+	toks := strings.Split(str, "|")
+	if len(toks) != 2 {
+		return stubOpaque{}, fmt.Errorf("invalid stubOpaque %q", str)
+	}
+	return stubOpaque{toks[0], toks[1]}, nil
+}
+
+func init() {
+	redmap.RegisterCodec(encodeStubOpaque, decodeStubOpaque)
+}
+
+func TestCodecGlobal(t *testing.T) {
+	type stru struct {
+		Opaque  stubOpaque
+		Pointer *stubOpaque
+	}
+	in := stru{Opaque: stubOpaque{"a", "b"}, Pointer: &stubOpaque{"c", "d"}}
+	expected := map[string]string{"Opaque": "a|b", "Pointer": "c|d"}
+	mp, err := redmap.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, mp)
+	}
+	var out stru
+	err = redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, out)
+	}
+}
+
+func TestCodecPerEncoder(t *testing.T) {
+	type stru struct{ V int }
+	hex := redmap.NewCodec(
+		func(v int) (string, error) { return fmt.Sprintf("%x", v), nil },
+		func(s string) (v int, err error) {
+			_, err = fmt.Sscanf(s, "%x", &v)
+			return v, err
+		},
+	)
+	var enc redmap.Encoder
+	enc.UseCodec(hex)
+	mp, err := enc.Marshal(stru{V: 255})
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if expected := map[string]string{"V": "ff"}; !reflect.DeepEqual(mp, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, mp)
+	}
+
+	var (
+		dec redmap.Decoder
+		out stru
+	)
+	dec.UseCodec(hex)
+	err = dec.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if out.V != 255 {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", 255, out.V)
+	}
+
+	// Codecs set on an Encoder must not leak into the package-level functions.
+	mp, err = redmap.Marshal(stru{V: 255})
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if expected := map[string]string{"V": "255"}; !reflect.DeepEqual(mp, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, mp)
+	}
+}
+
+func TestCodecNamed(t *testing.T) {
+	type stru struct {
+		Unix    time.Time  `redmap:"unix,codec=unix"`
+		UnixMs  *time.Time `redmap:"unixms,codec=unixms"`
+		RFC3339 time.Time  `redmap:"rfc3339,codec=rfc3339"`
+		Default time.Time  `redmap:"default"`
+	}
+	date := time.Date(2021, time.October, 1, 12, 30, 15, 500000000, time.UTC)
+	in := stru{Unix: date, UnixMs: &date, RFC3339: date, Default: date}
+	expected := map[string]string{
+		"unix":    "1633091415",
+		"unixms":  "1633091415500",
+		"rfc3339": "2021-10-01T12:30:15Z",
+		"default": "2021-10-01T12:30:15.5Z",
+	}
+	mp, err := redmap.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, mp)
+	}
+	var out stru
+	err = redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !out.Unix.Equal(date.Truncate(time.Second)) || !out.UnixMs.Equal(date) ||
+		!out.RFC3339.Equal(date.Truncate(time.Second)) || !out.Default.Equal(date) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tOut: %v", mp, out)
+	}
+}
+
+func TestCodecNamedInvalid(t *testing.T) {
+	tests := []interface{}{
+		// Codec doesn't exist.
+		&struct {
+			V time.Time `redmap:",codec=doesnotexist"`
+		}{},
+		// Codec doesn't match the field type.
+		&struct {
+			V int `redmap:",codec=unix"`
+		}{},
+	}
+	for _, test := range tests {
+		if _, err := redmap.Marshal(test); err == nil {
+			t.Fatalf("Marshal of %T did not return error", test)
+		}
+		if err := redmap.Unmarshal(map[string]string{"V": "0"}, test); err == nil {
+			t.Fatalf("Unmarshal of %T did not return error", test)
+		}
+	}
+}
+
+func TestCodecPointer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("NewCodec of a pointer type did not panic")
+		}
+	}()
+	redmap.NewCodec[*big.Int](nil, nil)
+}
@@ -0,0 +1,106 @@
+package redmap
+
+import "reflect"
+
+// config holds the settings shared by Encoder and Decoder.
+type config struct {
+	tagKeys []string
+	null    string
+	hasNull bool
+	codecs  map[reflect.Type]Codec
+
+	serializer Serializer
+
+	intBase      int
+	intPrefix    bool
+	floatFmt     byte
+	floatPrec    int
+	hasFloatPrec bool
+
+	maxDepth int
+
+	sep    string
+	hasSep bool
+	// nameFunc, if not nil, transforms the name of each field into its key.
+	nameFunc func(string) string
+}
+
+// SetTagKeys sets the struct tag keys to be consulted, in order, when looking for a field's
+// format string. The first key present in the field's tag is used, so that, for example,
+// SetTagKeys("redmap", "json") reuses json tags for fields lacking a redmap tag.
+// Whatever the key, the format string is interpreted as documented in Marshal and
+// unknown options, such as json's "string", are ignored.
+// By default, only the "redmap" key is consulted.
+func (c *config) SetTagKeys(keys ...string) {
+	c.tagKeys = append([]string(nil), keys...)
+}
+
+// SetNull sets the string representing a nil pointer. When set, Marshal writes repr
+// for nil pointer fields instead of the underlying type's zero value, and Unmarshal sets
+// a pointer field to nil when its value equals repr, thus preserving the difference
+// between a nil pointer and a pointer to a zero value. repr may be the empty string,
+// but it must differ from the representation of any non-nil pointer being marshaled:
+// for example, with SetNull(""), a *string pointing to "" cannot be told apart from
+// a nil one, so Marshal returns ErrAmbiguousNull.
+// Inlined pointer fields are represented by a single key, named as the field, holding repr.
+func (c *config) SetNull(repr string) {
+	c.null, c.hasNull = repr, true
+}
+
+// isNull reports whether str is the representation of a nil pointer.
+func (c *config) isNull(str string) bool {
+	return c.hasNull && str == c.null
+}
+
+// defaultMaxDepth is the default maximum nesting depth of inlined structs.
+const defaultMaxDepth = 1000
+
+// SetMaxDepth sets the maximum nesting depth of inlined structs, the outermost struct
+// having depth 1. Exceeding it causes ErrMaxDepth to be returned, rather than recursing
+// indefinitely with self-referential types. A depth of zero or less restores the default of 1000.
+func (c *config) SetMaxDepth(depth int) {
+	c.maxDepth = depth
+}
+
+func (c *config) maxDepthOrDefault() int {
+	if c.maxDepth <= 0 {
+		return defaultMaxDepth
+	}
+	return c.maxDepth
+}
+
+// SetSeparator sets the default separator between the name of an inlined field
+// and the names of its fields, which is "." unless stated otherwise. The `sep` option
+// of a field's format string takes precedence.
+func (c *config) SetSeparator(sep string) {
+	c.sep, c.hasSep = sep, true
+}
+
+// inlinePrefix returns the key prefix of the fields of an inlined struct
+// having tags, given the key prefix of the struct containing it.
+// Separator returns the separator set with SetSeparator, and whether one was set.
+func (c *config) Separator() (sep string, ok bool) {
+	return c.sep, c.hasSep
+}
+
+func (c *config) inlinePrefix(prefix string, tags structTags) string {
+	if tags.noprefix {
+		return prefix
+	}
+	sep := inlineSep
+	switch {
+	case tags.customSep:
+		sep = tags.sep
+	case c.hasSep:
+		sep = c.sep
+	}
+	return prefix + tags.name + sep
+}
+
+// keyName returns the key of a field named name.
+func (c *config) keyName(name string) string {
+	if c.nameFunc == nil {
+		return name
+	}
+	return c.nameFunc(name)
+}
@@ -0,0 +1,180 @@
+// Package csv reads and writes slices of structs as CSV tables, using the conversions of redmap.
+//
+// Each struct is a row, and each key produced by marshaling it is a column. The header row
+// is the union of the keys of all rows, in the order they are first seen, so that rows
+// lacking a key, for example because of the `omitempty` option, have an empty cell there.
+// Conversely, empty cells are treated as missing keys when reading a table.
+package csv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/livingsilver94/redmap"
+)
+
+// Encoder writes slices of structs as CSV tables to an output stream.
+type Encoder struct {
+	// Options is used to marshal each row into cells.
+	Options redmap.Encoder
+	// Comma is the field delimiter. It defaults to ',', which is also used if Comma is zero.
+	Comma rune
+	w     io.Writer
+}
+
+// NewEncoder returns a new Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{Comma: ',', w: w}
+}
+
+// Encode writes rows, which must be a slice or an array of values accepted
+// by redmap.Marshal, to the stream as a CSV table with a header row.
+// Columns are ordered as returned by redmap.Encoder.MarshalOrdered.
+// Nothing is written if rows produce no keys, for example if rows is empty.
+func (e *Encoder) Encode(rows interface{}) error {
+	val := reflect.ValueOf(rows)
+	if kind := val.Kind(); kind != reflect.Slice && kind != reflect.Array {
+		return fmt.Errorf("%T is not a slice or an array", rows)
+	}
+	var header []string
+	columns := make(map[string]int)
+	records := make([]map[string]string, val.Len())
+	for i := range records {
+		pairs, err := e.Options.MarshalOrdered(val.Index(i).Interface())
+		if err != nil {
+			return fmt.Errorf("row %d: %w", i+1, err)
+		}
+		records[i] = make(map[string]string, len(pairs))
+		for _, pair := range pairs {
+			if _, ok := columns[pair.Key]; !ok {
+				columns[pair.Key] = len(header)
+				header = append(header, pair.Key)
+			}
+			records[i][pair.Key] = pair.Value
+		}
+	}
+
+	if len(header) == 0 {
+		// Nothing to write, not even a header.
+		return nil
+	}
+	w := csv.NewWriter(e.w)
+	w.Comma = commaOrDefault(e.Comma)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(header))
+	for _, rec := range records {
+		for i, key := range header {
+			record[i] = rec[key]
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// MarshalTable writes rows to w as a CSV table. See Encoder.Encode for details.
+func MarshalTable[T any](rows []T, w io.Writer) error {
+	return NewEncoder(w).Encode(rows)
+}
+
+// Decoder reads slices of structs from CSV tables in an input stream.
+type Decoder struct {
+	// Options is used to unmarshal the cells of each row.
+	Options redmap.Decoder
+	// Comma is the field delimiter. It defaults to ',', which is also used if Comma is zero.
+	Comma rune
+	r     io.Reader
+}
+
+// NewDecoder returns a new Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{Comma: ',', r: r}
+}
+
+// Decode reads the whole stream as a CSV table with a header row and stores its rows
+// into the slice pointed to by rows, replacing it. Each row is unmarshaled as in
+// redmap.Unmarshal into a new element, with the header giving the keys.
+// An empty stream results in an empty slice. If an error occurs, rows is left untouched.
+func (d *Decoder) Decode(rows interface{}) error {
+	ptr := reflect.ValueOf(rows)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%T is not a non-nil pointer to a slice", rows)
+	}
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+
+	r := csv.NewReader(d.r)
+	r.Comma = commaOrDefault(d.Comma)
+	header, err := r.Read()
+	if err == io.EOF {
+		slice.Set(reflect.MakeSlice(slice.Type(), 0, 0))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i, key := range header {
+		for _, prev := range header[:i] {
+			if key == prev {
+				return fmt.Errorf("duplicate column %q", key)
+			}
+		}
+	}
+
+	// Build a new slice, so that the caller's one is untouched on error.
+	ret := reflect.MakeSlice(slice.Type(), 0, 0)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		mp := make(map[string]string, len(record))
+		for i, cell := range record {
+			if cell != "" {
+				mp[header[i]] = cell
+			}
+		}
+		elem := newElem(elemType)
+		if err := d.Options.Unmarshal(mp, elem.Interface()); err != nil {
+			line, _ := r.FieldPos(0)
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if elemType.Kind() != reflect.Ptr {
+			elem = elem.Elem()
+		}
+		ret = reflect.Append(ret, elem)
+	}
+	slice.Set(ret)
+	return nil
+}
+
+// newElem returns a pointer to a new value to be unmarshaled into,
+// which is an element of type typ if typ is a pointer.
+func newElem(typ reflect.Type) reflect.Value {
+	if typ.Kind() == reflect.Ptr {
+		return reflect.New(typ.Elem())
+	}
+	return reflect.New(typ)
+}
+
+// commaOrDefault returns comma, or ',' if comma is zero.
+func commaOrDefault(comma rune) rune {
+	if comma == 0 {
+		return ','
+	}
+	return comma
+}
+
+// UnmarshalTable reads a CSV table from r into rows. See Decoder.Decode for details.
+func UnmarshalTable[T any](r io.Reader, rows *[]T) error {
+	return NewDecoder(r).Decode(rows)
+}
@@ -0,0 +1,145 @@
+package csv_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/livingsilver94/redmap/csv"
+)
+
+type entry struct {
+	ID    int    `redmap:"id"`
+	Name  string `redmap:"name"`
+	Note  string `redmap:"note,omitempty"`
+	Owner struct {
+		Email string `redmap:"email"`
+	} `redmap:"owner,inline"`
+}
+
+func TestMarshalTable(t *testing.T) {
+	in := []entry{{ID: 1, Name: "first"}, {ID: 2, Name: "second, \"quoted\"", Note: "late column"}}
+	in[0].Owner.Email = "a@example.com"
+	expected := "id,name,owner.email,note\n" +
+		"1,first,a@example.com,\n" +
+		"2,\"second, \"\"quoted\"\"\",,late column\n"
+	var buf bytes.Buffer
+	err := csv.MarshalTable(in, &buf)
+	if err != nil {
+		t.Fatalf("MarshalTable returned unexpected error %q", err)
+	}
+	if buf.String() != expected {
+		t.Fatalf("MarshalTable's output doesn't match the expected value\n\tExpected: %q\n\tOut: %q", expected, buf.String())
+	}
+
+	var out []entry
+	err = csv.UnmarshalTable(&buf, &out)
+	if err != nil {
+		t.Fatalf("UnmarshalTable returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("UnmarshalTable's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, out)
+	}
+}
+
+func TestMarshalTableEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	err := csv.MarshalTable([]entry(nil), &buf)
+	if err != nil {
+		t.Fatalf("MarshalTable returned unexpected error %q", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("MarshalTable wrote %q instead of nothing", buf.String())
+	}
+	out := []entry{{ID: 1}}
+	err = csv.UnmarshalTable(&buf, &out)
+	if err != nil {
+		t.Fatalf("UnmarshalTable returned unexpected error %q", err)
+	}
+	if len(out) != 0 {
+		t.Fatalf("UnmarshalTable returned %v instead of an empty slice", out)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	in := "id;name\n1;one\n2;two\n"
+	expected := []*entry{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}}
+	dec := csv.NewDecoder(strings.NewReader(in))
+	dec.Comma = ';'
+	var out []*entry
+	err := dec.Decode(&out)
+	if err != nil {
+		t.Fatalf("Decode returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Decode's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestUnmarshalTableInvalid(t *testing.T) {
+	tests := []string{
+		"id,id\n1,2\n",
+		"id,name\n1\n",
+		"id,name\n1,one\nnotanumber,two\n",
+	}
+	for _, test := range tests {
+		var out []entry
+		if err := csv.UnmarshalTable(strings.NewReader(test), &out); err == nil {
+			t.Fatalf("UnmarshalTable of %q did not return error", test)
+		}
+	}
+
+	var out []entry
+	err := csv.UnmarshalTable(strings.NewReader("id\nx\n"), &out)
+	if !errors.Is(err, strconv.ErrSyntax) || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("UnmarshalTable returned %q instead of a syntax error at line 2", err)
+	}
+
+	out = []entry{{ID: 1}, {ID: 2}}
+	expected := []entry{{ID: 1}, {ID: 2}}
+	err = csv.UnmarshalTable(strings.NewReader("id\n5\nx\n"), &out)
+	if err == nil {
+		t.Fatal("UnmarshalTable of an invalid row did not return error")
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("UnmarshalTable modified the slice on error\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestEncoderInvalidComma(t *testing.T) {
+	var buf bytes.Buffer
+	enc := csv.NewEncoder(&buf)
+	enc.Comma = '"'
+	if err := enc.Encode([]entry{{ID: 1}}); err == nil {
+		t.Fatal("Encode with an invalid delimiter did not return error")
+	}
+}
+
+func TestZeroComma(t *testing.T) {
+	in := []entry{{ID: 1, Name: "one"}}
+	expected := "id,name,owner.email\n1,one,\n"
+	var buf bytes.Buffer
+	enc := csv.NewEncoder(&buf)
+	enc.Comma = 0
+	err := enc.Encode(in)
+	if err != nil {
+		t.Fatalf("Encode returned unexpected error %q", err)
+	}
+	if buf.String() != expected {
+		t.Fatalf("Encode's output doesn't match the expected value\n\tExpected: %q\n\tOut: %q", expected, buf.String())
+	}
+
+	var out []entry
+	dec := csv.NewDecoder(&buf)
+	dec.Comma = 0
+	err = dec.Decode(&out)
+	if err != nil {
+		t.Fatalf("Decode returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("Decode's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, out)
+	}
+}
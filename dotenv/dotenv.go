@@ -0,0 +1,324 @@
+// Package dotenv reads and writes structs as .env files, using the conversions of redmap.
+//
+// A .env file is made of lines in the KEY=value form. Blank lines and lines starting with "#"
+// are ignored, and so is an "export" keyword before a key. A value may be enclosed in double
+// quotes, in which case it can span multiple lines and contain the escape sequences \n, \r, \t,
+// \" and \\, or in single quotes, in which case it is taken literally. An unquoted value ends
+// at the end of the line or before a "#" preceded by a space, which starts a comment.
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/livingsilver94/redmap"
+)
+
+// Encoder writes structs as .env files to an output stream.
+type Encoder struct {
+	// Options controls how fields are marshaled before being written.
+	Options redmap.Encoder
+	w       io.Writer
+}
+
+// NewEncoder returns a new Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes v to the stream as a .env file, one line per key
+// in the order returned by redmap.Encoder.MarshalOrdered.
+// Values are quoted when needed.
+func (e *Encoder) Encode(v interface{}) error {
+	pairs, err := e.Options.MarshalOrdered(v)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(e.w)
+	for _, pair := range pairs {
+		if !validKey(pair.Key) {
+			return fmt.Errorf("invalid key %q", pair.Key)
+		}
+		w.WriteString(pair.Key)
+		w.WriteByte('=')
+		w.WriteString(quote(pair.Value))
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+// Encode writes v to w as a .env file. See Encoder.Encode for details.
+func Encode(w io.Writer, v interface{}) error {
+	return NewEncoder(w).Encode(v)
+}
+
+// Decoder reads structs from .env files in an input stream.
+type Decoder struct {
+	// Options controls how the parsed values are unmarshaled into fields.
+	Options redmap.Decoder
+	r       io.Reader
+}
+
+// NewDecoder returns a new Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Decode reads the whole stream as a .env file and unmarshals it into v,
+// which must be a pointer as in redmap.Unmarshal.
+func (d *Decoder) Decode(v interface{}) error {
+	mp, err := Read(d.r)
+	if err != nil {
+		return err
+	}
+	return d.Options.Unmarshal(mp, v)
+}
+
+// Decode reads a .env file from r and unmarshals it into v. See Decoder.Decode for details.
+func Decode(r io.Reader, v interface{}) error {
+	return NewDecoder(r).Decode(v)
+}
+
+// Read parses a .env file from r into a map. If a key appears more than once, the last value wins.
+func Read(r io.Reader) (map[string]string, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{src: string(src), line: 1}
+	ret := make(map[string]string)
+	for {
+		key, value, ok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return ret, nil
+		}
+		ret[key] = value
+	}
+}
+
+// SyntaxError describes a malformed line of a .env file.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+const exportKeyword = "export"
+
+// parser reads key-value pairs out of a .env file.
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+// next returns the next key-value pair, or false if there are no more.
+func (p *parser) next() (key, value string, ok bool, err error) {
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return "", "", false, nil
+		}
+		switch p.src[p.pos] {
+		case '\n':
+			p.pos++
+			p.line++
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+		break
+	}
+	key = p.word()
+	if key == exportKeyword && p.pos < len(p.src) && isSpace(p.src[p.pos]) {
+		p.skipSpaces()
+		key = p.word()
+	}
+	if !validKey(key) {
+		return "", "", false, p.errorf("invalid key %q", key)
+	}
+	p.skipSpaces()
+	if p.eof() || p.src[p.pos] != '=' {
+		return "", "", false, p.errorf("missing \"=\" after key %q", key)
+	}
+	p.pos++
+	p.skipSpaces()
+	value, err = p.value()
+	return key, value, err == nil, err
+}
+
+// word consumes a sequence of characters up to a space, "=" or a line break.
+func (p *parser) word() string {
+	start := p.pos
+	for !p.eof() && !isSpace(p.src[p.pos]) && p.src[p.pos] != '=' && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// value consumes a value and the rest of its line.
+func (p *parser) value() (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+	var (
+		value string
+		err   error
+	)
+	switch p.src[p.pos] {
+	case '"':
+		value, err = p.doubleQuoted()
+	case '\'':
+		value, err = p.singleQuoted()
+	default:
+		return p.unquoted(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	p.skipSpaces()
+	switch {
+	case p.eof():
+	case p.src[p.pos] == '#':
+		p.skipLine()
+	case p.src[p.pos] != '\n':
+		return "", p.errorf("unexpected %q after quoted value", p.src[p.pos])
+	}
+	return value, nil
+}
+
+func (p *parser) unquoted() string {
+	start := p.pos
+	for !p.eof() && p.src[p.pos] != '\n' {
+		if p.src[p.pos] == '#' && p.pos > 0 && isSpace(p.src[p.pos-1]) {
+			break
+		}
+		p.pos++
+	}
+	value := strings.TrimRight(p.src[start:p.pos], " \t\r")
+	p.skipLine()
+	return value
+}
+
+func (p *parser) doubleQuoted() (string, error) {
+	startLine := p.line
+	p.pos++ // Opening quote.
+	var b strings.Builder
+	for !p.eof() {
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\n':
+			p.line++
+		case '\\':
+			if p.eof() {
+				continue
+			}
+			esc := p.src[p.pos]
+			p.pos++
+			switch esc {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case '"', '\\':
+				c = esc
+			default:
+				// Unknown escape sequences are kept verbatim.
+				b.WriteByte('\\')
+				c = esc
+			}
+		}
+		b.WriteByte(c)
+	}
+	return "", &SyntaxError{Line: startLine, Msg: "unterminated double-quoted value"}
+}
+
+func (p *parser) singleQuoted() (string, error) {
+	startLine := p.line
+	p.pos++ // Opening quote.
+	end := strings.IndexByte(p.src[p.pos:], '\'')
+	if end < 0 {
+		return "", &SyntaxError{Line: startLine, Msg: "unterminated single-quoted value"}
+	}
+	value := p.src[p.pos : p.pos+end]
+	p.line += strings.Count(value, "\n")
+	p.pos += end + 1
+	return value, nil
+}
+
+// skipSpaces consumes spaces, tabs and carriage returns.
+func (p *parser) skipSpaces() {
+	for !p.eof() && isSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// skipLine consumes everything up to the next line, included.
+func (p *parser) skipLine() {
+	for !p.eof() && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+	if !p.eof() {
+		p.pos++
+		p.line++
+	}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+// validKey reports whether key can be written unquoted as a key.
+func validKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, " \t\r\n=#\"'\\")
+}
+
+// quote returns value as it must be written in a .env file,
+// enclosing it in double quotes if needed.
+func quote(value string) string {
+	if !strings.ContainsAny(value, " \t\r\n#\"'\\") {
+		return value
+	}
+	var b strings.Builder
+	b.Grow(len(value) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
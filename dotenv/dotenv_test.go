@@ -0,0 +1,107 @@
+package dotenv_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/livingsilver94/redmap/dotenv"
+)
+
+type config struct {
+	Name    string `redmap:"NAME"`
+	Port    int    `redmap:"PORT"`
+	Message string `redmap:"MESSAGE"`
+	Debug   bool   `redmap:"DEBUG"`
+	DB      struct {
+		Host string `redmap:"HOST"`
+	} `redmap:"DB,inline,sep=_"`
+}
+
+func TestEncode(t *testing.T) {
+	var in config
+	in.Name = "app"
+	in.Port = 8080
+	in.Message = "hello \"world\"\n# not a comment"
+	in.DB.Host = "localhost"
+	expected := `NAME=app
+PORT=8080
+MESSAGE="hello \"world\"\n# not a comment"
+DEBUG=false
+DB_HOST=localhost
+`
+	var buf bytes.Buffer
+	err := dotenv.Encode(&buf, in)
+	if err != nil {
+		t.Fatalf("Encode returned unexpected error %q", err)
+	}
+	if buf.String() != expected {
+		t.Fatalf("Encode's output doesn't match the expected value\n\tExpected: %q\n\tOut: %q", expected, buf.String())
+	}
+
+	var out config
+	err = dotenv.Decode(&buf, &out)
+	if err != nil {
+		t.Fatalf("Decode returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("Decode's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, out)
+	}
+}
+
+func TestRead(t *testing.T) {
+	in := `# A comment.
+
+export NAME=app
+PORT = 8080 # Trailing comment.
+COLOR=#ff0000
+EMPTY=
+SPACED=  some value  
+DOUBLE="line1
+line2\t\"quoted\" \\ \x"  # Comment.
+SINGLE='literal \n "value"'
+CRLF=value` + "\r\n" + `LAST=last`
+	expected := map[string]string{
+		"NAME":   "app",
+		"PORT":   "8080",
+		"COLOR":  "#ff0000",
+		"EMPTY":  "",
+		"SPACED": "some value",
+		"DOUBLE": "line1\nline2\t\"quoted\" \\ \\x",
+		"SINGLE": `literal \n "value"`,
+		"CRLF":   "value",
+		"LAST":   "last",
+	}
+	out, err := dotenv.Read(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Read returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Read's output doesn't match the expected value\n\tExpected: %q\n\tOut: %q", expected, out)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		In   string
+		Line int
+	}{
+		{In: "NOVALUE", Line: 1},
+		{In: "A=1\n=value", Line: 2},
+		{In: "A=1\n\nB=\"unterminated", Line: 3},
+		{In: "A='unterminated", Line: 1},
+		{In: "A=\"quoted\" garbage", Line: 1},
+	}
+	for _, test := range tests {
+		_, err := dotenv.Read(strings.NewReader(test.In))
+		var syntaxErr *dotenv.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("Read of %q returned %v instead of a syntax error", test.In, err)
+		}
+		if syntaxErr.Line != test.Line {
+			t.Fatalf("Read of %q returned error at line %d instead of %d", test.In, syntaxErr.Line, test.Line)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package redmap
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// envSep is the default separator of inlined fields in environment variables.
+const envSep = "_"
+
+// MarshalEnv returns the environment variables representing v, in the "KEY=value" form
+// and sorted by key. It follows the same rules as Marshal, except that field names are
+// converted to upper snake case, e.g. "DBHost" becomes "DB_HOST", inlined fields are separated
+// by "_" unless Encoder.SetSeparator is used, and all keys are prefixed by prefix, e.g. "APP_".
+//
+// MarshalEnv is equivalent to calling MarshalEnv on a zero Encoder.
+func MarshalEnv(v interface{}, prefix string) ([]string, error) {
+	var enc Encoder
+	return enc.MarshalEnv(v, prefix)
+}
+
+// MarshalEnv works like the MarshalEnv function, but with the settings of e.
+func (e *Encoder) MarshalEnv(v interface{}, prefix string) ([]string, error) {
+	val, err := validValue(v)
+	if err != nil {
+		return nil, err
+	}
+	enc := *e
+	enc.nameFunc = upperSnakeCase
+	if !enc.hasSep {
+		enc.SetSeparator(envSep)
+	}
+	mp := make(mapSink)
+	if err := enc.marshal(mp, prefix, val); err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(mp))
+	for k, v := range mp {
+		ret = append(ret, k+"="+v)
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// UnmarshalEnv sets v's fields according to the environment variables of the current process.
+// It is a shorthand for UnmarshalEnviron(os.Environ(), v, prefix).
+//
+// UnmarshalEnv is equivalent to calling UnmarshalEnv on a zero Decoder.
+func UnmarshalEnv(v interface{}, prefix string) error {
+	var dec Decoder
+	return dec.UnmarshalEnv(v, prefix)
+}
+
+// UnmarshalEnv works like the UnmarshalEnv function, but with the settings of d.
+func (d *Decoder) UnmarshalEnv(v interface{}, prefix string) error {
+	return d.UnmarshalEnviron(os.Environ(), v, prefix)
+}
+
+// UnmarshalEnviron sets v's fields according to environ, a list of environment variables
+// in the "KEY=value" form as returned by os.Environ. It uses the inverse of the naming
+// rules of MarshalEnv, so that, with prefix "APP_", the variable "APP_DB_HOST"
+// is stored into the field Host of the field DB, if the latter is inlined.
+//
+// UnmarshalEnviron is equivalent to calling UnmarshalEnviron on a zero Decoder.
+func UnmarshalEnviron(environ []string, v interface{}, prefix string) error {
+	var dec Decoder
+	return dec.UnmarshalEnviron(environ, v, prefix)
+}
+
+// UnmarshalEnviron works like the UnmarshalEnviron function, but with the settings of d.
+func (d *Decoder) UnmarshalEnviron(environ []string, v interface{}, prefix string) error {
+	val, err := ptrValidValue(v)
+	if err != nil {
+		return err
+	}
+	data := make(mapSource, len(environ))
+	for _, env := range environ {
+		i := strings.Index(env, "=")
+		if i <= 0 {
+			// Not a variable, or a special variable such as "=C:" on Windows.
+			continue
+		}
+		data[env[:i]] = env[i+1:]
+	}
+	dec := *d
+	dec.nameFunc = upperSnakeCase
+	if !dec.hasSep {
+		dec.SetSeparator(envSep)
+	}
+	return dec.unmarshal(data, prefix, val)
+}
+
+// upperSnakeCase converts name to upper snake case. A word boundary is found between
+// a lower-case letter or digit and an upper-case letter, and at the end of a sequence
+// of upper-case letters followed by a lower-case one, e.g. "DBHost" becomes "DB_HOST".
+func upperSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
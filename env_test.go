@@ -0,0 +1,83 @@
+package redmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+type envConfig struct {
+	Debug bool
+	DB    struct {
+		Host    string
+		Port    int
+		MaxConn int `redmap:"maxConnections"`
+	} `redmap:",inline"`
+	HTTPAddr string
+	Ignored  string `redmap:"-"`
+}
+
+func TestMarshalEnv(t *testing.T) {
+	var in envConfig
+	in.Debug = true
+	in.DB.Host = "localhost"
+	in.DB.Port = 6379
+	in.DB.MaxConn = 10
+	in.HTTPAddr = ":8080"
+	expected := []string{
+		"APP_DB_HOST=localhost",
+		"APP_DB_MAX_CONNECTIONS=10",
+		"APP_DB_PORT=6379",
+		"APP_DEBUG=true",
+		"APP_HTTP_ADDR=:8080",
+	}
+	out, err := redmap.MarshalEnv(in, "APP_")
+	if err != nil {
+		t.Fatalf("MarshalEnv returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("MarshalEnv's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestUnmarshalEnviron(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"=C:=C:\\",
+		"APP_DEBUG=true",
+		"APP_DB_HOST=localhost",
+		"APP_DB_PORT=6379",
+		"APP_DB_MAX_CONNECTIONS=10",
+		"APP_HTTP_ADDR=:8080",
+		"APP_IGNORED=ignored",
+		"OTHER_DB_HOST=other",
+	}
+	var expected envConfig
+	expected.Debug = true
+	expected.DB.Host = "localhost"
+	expected.DB.Port = 6379
+	expected.DB.MaxConn = 10
+	expected.HTTPAddr = ":8080"
+
+	var out envConfig
+	err := redmap.UnmarshalEnviron(environ, &out, "APP_")
+	if err != nil {
+		t.Fatalf("UnmarshalEnviron returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("UnmarshalEnviron's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", environ, expected, out)
+	}
+}
+
+func TestUnmarshalEnv(t *testing.T) {
+	t.Setenv("REDMAPTEST_DB_HOST", "envhost")
+	var out envConfig
+	err := redmap.UnmarshalEnv(&out, "REDMAPTEST_")
+	if err != nil {
+		t.Fatalf("UnmarshalEnv returned unexpected error %q", err)
+	}
+	if out.DB.Host != "envhost" {
+		t.Fatalf("UnmarshalEnv's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", "envhost", out.DB.Host)
+	}
+}
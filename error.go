@@ -13,6 +13,18 @@ var (
 	// ErrNoCodec is returned when a type cannot be marshaled or unmarshaled,
 	// i.e. it is neither a struct nor implements StringMap(Un)marshaler.
 	ErrNoCodec = errors.New("not an encodable or decodable type")
+	// ErrAmbiguousKey is returned when a case-insensitive Decoder finds
+	// more than one map key matching the same struct field.
+	ErrAmbiguousKey = errors.New("ambiguous")
+	// ErrCycle is returned when marshaling a value that references itself
+	// through pointers to inlined structs.
+	ErrCycle = errors.New("part of a reference cycle")
+	// ErrMaxDepth is returned when inlined structs are nested deeper than
+	// the maximum depth set with SetMaxDepth.
+	ErrMaxDepth = errors.New("nested too deeply")
+	// ErrAmbiguousNull is returned when marshaling a non-nil pointer whose value
+	// is written as the null representation set with SetNull.
+	ErrAmbiguousNull = errors.New("indistinguishable from a nil pointer")
 )
 
 func errIs(something interface{}, err error) error {
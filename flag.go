@@ -0,0 +1,160 @@
+package redmap
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// flagSep is the default separator of inlined fields in flag names.
+const flagSep = "-"
+
+// BindFlags defines a flag in fs for each key that Marshal returns for v, which must
+// be a pointer to a struct. Inlined fields are separated by "-" unless Decoder.SetSeparator
+// is used, so that, for example, the field Host of the inlined field "db" is bound
+// to the flag "db-host" if named "host". The default value of each flag is the current
+// value of its field, and its usage message is given by the `usage` option of the field's
+// format string, which extends to the end of the format string so that it may contain commas:
+//
+//	Host string `redmap:"host,usage=database host, without port"`
+//
+// Every field gets a flag: `omitempty` and `omitzero` are ignored, and nil pointers to
+// inlined structs are walked as if they pointed to a zero value.
+//
+// When fs is parsed, the value of each flag is stored into its field as Unmarshal
+// would do, leaving other fields untouched. The keys of an inlined StringMapUnmarshaler
+// are unmarshaled together with the current values of the others. Boolean fields are bound to boolean flags,
+// which may be set with no value, as in "-debug".
+//
+// An error is returned if v cannot be marshaled or a flag is already defined in fs.
+//
+// BindFlags is equivalent to calling BindFlags on a zero Decoder.
+func BindFlags(fs *flag.FlagSet, v interface{}) error {
+	var dec Decoder
+	return dec.BindFlags(fs, v)
+}
+
+// BindFlags works like the BindFlags function, but with the settings of d.
+// The settings are copied, so that changing d afterwards has no effect.
+func (d *Decoder) BindFlags(fs *flag.FlagSet, v interface{}) error {
+	val, err := ptrValidValue(v)
+	if err != nil {
+		return err
+	}
+	dec := *d
+	// Flags are set one at a time, and must not reset the fields of the others.
+	dec.resetMissing = false
+	if !dec.hasSep {
+		dec.SetSeparator(flagSep)
+	}
+	enc := Encoder{config: dec.config}
+	out := flagSink{fs: fs, dec: &dec, val: val}
+	if err := enc.marshal(&out, "", val); err != nil {
+		return err
+	}
+	return out.err
+}
+
+// flagSink is the sink of BindFlags, which defines a flag for each key it receives.
+type flagSink struct {
+	fs  *flag.FlagSet
+	dec *Decoder
+	// val is the struct that flags are stored into.
+	val reflect.Value
+	// err is the first error occurred while defining flags.
+	err error
+}
+
+func (s *flagSink) set(key, value string) {
+	s.define(&flagValue{key: key, str: value}, "")
+}
+
+// exhaustive makes flagSink an exhaustiveSink, so that every field gets a flag
+// regardless of the current value of v.
+func (s *flagSink) exhaustive() {}
+
+func (s *flagSink) setMap(prefix string, mp map[string]string) {
+	group := &flagGroup{values: make(map[string]string, len(mp))}
+	keys := make([]string, 0, len(mp))
+	for k, v := range mp {
+		group.values[prefix+k] = v
+		keys = append(keys, k)
+	}
+	// Define flags in a predictable order, so that errors are too.
+	sort.Strings(keys)
+	for _, k := range keys {
+		s.define(&flagValue{key: prefix + k, str: mp[k], group: group}, "")
+	}
+}
+
+func (s *flagSink) setField(key, str string, value reflect.Value, tags structTags) {
+	typ := value.Type()
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	_, custom, _ := s.dec.encoderFor(typ, tags)
+	isBool := typ.Kind() == reflect.Bool && !custom && !tags.json
+	s.define(&flagValue{key: key, str: str, isBool: isBool}, tags.usage)
+}
+
+// define defines the flag of val, named after its key.
+func (s *flagSink) define(val *flagValue, usage string) {
+	if s.err != nil {
+		return
+	}
+	switch key := val.key; {
+	case key == "" || strings.HasPrefix(key, "-") || strings.Contains(key, "="):
+		s.err = fmt.Errorf("%q is not a valid flag name", key)
+	case s.fs.Lookup(key) != nil:
+		s.err = fmt.Errorf("flag %q is already defined", key)
+	default:
+		val.sink = s
+		s.fs.Var(val, key, usage)
+	}
+}
+
+// flagGroup holds the current values of the keys of an inlined StringMapUnmarshaler,
+// which must be unmarshaled all together.
+type flagGroup struct {
+	values map[string]string
+}
+
+// flagValue is the flag.Value of a key bound by BindFlags.
+type flagValue struct {
+	sink   *flagSink
+	key    string
+	str    string
+	isBool bool
+	// group is not nil if the key belongs to an inlined StringMapUnmarshaler.
+	group *flagGroup
+}
+
+func (f *flagValue) String() string {
+	return f.str
+}
+
+func (f *flagValue) Set(str string) error {
+	src := mapSource{f.key: str}
+	if f.group != nil {
+		// Pass the other keys too, or UnmarshalStringMap would not see them.
+		for k, v := range f.group.values {
+			if k != f.key {
+				src[k] = v
+			}
+		}
+	}
+	if err := f.sink.dec.unmarshal(src, "", f.sink.val); err != nil {
+		return err
+	}
+	f.str = str
+	if f.group != nil {
+		f.group.values[f.key] = str
+	}
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
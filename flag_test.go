@@ -0,0 +1,170 @@
+package redmap_test
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+type flagConfig struct {
+	Name    string  `redmap:"name,usage=name of the service, as shown in logs"`
+	Port    int     `redmap:"port,base=0x"`
+	Debug   bool    `redmap:"debug"`
+	Timeout *string `redmap:"timeout"`
+	DB      struct {
+		Host string `redmap:"host,usage=database host"`
+	} `redmap:"db,inline"`
+	Ignored string `redmap:"-"`
+}
+
+func TestBindFlags(t *testing.T) {
+	conf := flagConfig{Name: "svc", Port: 80, Ignored: "untouched"}
+	conf.DB.Host = "localhost"
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	err := redmap.BindFlags(fs, &conf)
+	if err != nil {
+		t.Fatalf("BindFlags returned unexpected error %q", err)
+	}
+
+	defaults := map[string]string{"name": "svc", "port": "0x50", "debug": "false", "timeout": "", "db-host": "localhost"}
+	usages := map[string]string{"name": "name of the service, as shown in logs", "db-host": "database host"}
+	for name, def := range defaults {
+		fl := fs.Lookup(name)
+		if fl == nil {
+			t.Fatalf("BindFlags did not define flag %q", name)
+		}
+		if fl.DefValue != def || fl.Usage != usages[name] {
+			t.Fatalf("flag %q has default %q and usage %q, expected %q and %q", name, fl.DefValue, fl.Usage, def, usages[name])
+		}
+	}
+	if fs.Lookup("Ignored") != nil {
+		t.Fatalf("BindFlags defined a flag for an ignored field")
+	}
+
+	err = fs.Parse([]string{"-port", "0xff", "-debug", "-db-host=example.com", "-timeout", "5s"})
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error %q", err)
+	}
+	timeout := "5s"
+	expected := flagConfig{Name: "svc", Port: 255, Debug: true, Timeout: &timeout, Ignored: "untouched"}
+	expected.DB.Host = "example.com"
+	if !reflect.DeepEqual(conf, expected) {
+		t.Fatalf("BindFlags's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, conf)
+	}
+}
+
+func TestBindFlagsSeparator(t *testing.T) {
+	var conf flagConfig
+	var dec redmap.Decoder
+	dec.SetSeparator(".")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := dec.BindFlags(fs, &conf); err != nil {
+		t.Fatalf("BindFlags returned unexpected error %q", err)
+	}
+	if fs.Lookup("db.host") == nil {
+		t.Fatalf("BindFlags did not use the custom separator")
+	}
+}
+
+func TestBindFlagsInvalid(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.String("name", "", "")
+	if err := redmap.BindFlags(fs, &flagConfig{}); err == nil || !strings.Contains(err.Error(), "already defined") {
+		t.Fatalf("BindFlags returned %v with an already defined flag", err)
+	}
+
+	tests := []interface{}{
+		flagConfig{},
+		&struct {
+			V int `redmap:"-v"`
+		}{},
+		&struct {
+			V int `redmap:"a=b"`
+		}{},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		if err := redmap.BindFlags(fs, test); err == nil {
+			t.Fatalf("BindFlags of %#v did not return error", test)
+		}
+	}
+
+	var conf flagConfig
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := redmap.BindFlags(fs, &conf); err != nil {
+		t.Fatalf("BindFlags returned unexpected error %q", err)
+	}
+	if err := fs.Parse([]string{"-port", "notanumber"}); err == nil {
+		t.Fatalf("Parse of an invalid value did not return error")
+	}
+}
+
+// flagPair implements both redmap.StringMapMarshaler and redmap.StringMapUnmarshaler.
+type flagPair struct {
+	stubMapUnmarshaler
+}
+
+func (p flagPair) MarshalStringMap() (map[string]string, error) {
+	return map[string]string{"Field1": p.Field1, "Field2": p.Field2}, nil
+}
+
+func TestBindFlagsEveryField(t *testing.T) {
+	type inner struct {
+		Host string `redmap:"host"`
+	}
+	var conf struct {
+		Port int    `redmap:"port,omitempty"`
+		Name string `redmap:"name,omitzero"`
+		DB   *inner `redmap:"db,inline"`
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := redmap.BindFlags(fs, &conf); err != nil {
+		t.Fatalf("BindFlags returned unexpected error %q", err)
+	}
+	for _, name := range []string{"port", "name", "db-host"} {
+		if fs.Lookup(name) == nil {
+			t.Fatalf("BindFlags did not define flag %q", name)
+		}
+	}
+
+	err := fs.Parse([]string{"-port", "8080", "-db-host", "example.com"})
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error %q", err)
+	}
+	if conf.Port != 8080 || conf.DB == nil || conf.DB.Host != "example.com" {
+		t.Fatalf("BindFlags's output doesn't match the expected value\n\tOut: %+v", conf)
+	}
+}
+
+func TestBindFlagsStringMap(t *testing.T) {
+	var conf struct {
+		Pair flagPair `redmap:"pair,inline"`
+	}
+	conf.Pair.Field1 = "a"
+	conf.Pair.Field2 = "b"
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := redmap.BindFlags(fs, &conf); err != nil {
+		t.Fatalf("BindFlags returned unexpected error %q", err)
+	}
+
+	err := fs.Parse([]string{"-pair-Field1", "c"})
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error %q", err)
+	}
+	if conf.Pair.Field1 != "c" || conf.Pair.Field2 != "b" {
+		t.Fatalf("BindFlags's output doesn't match the expected value\n\tOut: %+v", conf.Pair)
+	}
+	err = fs.Parse([]string{"-pair-Field2", "d"})
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error %q", err)
+	}
+	if conf.Pair.Field1 != "c" || conf.Pair.Field2 != "d" {
+		t.Fatalf("BindFlags's output doesn't match the expected value\n\tOut: %+v", conf.Pair)
+	}
+}
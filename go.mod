@@ -1,3 +1,3 @@
 module github.com/livingsilver94/redmap
 
-go 1.17
+go 1.18
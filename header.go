@@ -0,0 +1,107 @@
+package redmap
+
+import (
+	"net/http"
+	"net/textproto"
+)
+
+// headerSep is the default separator of inlined fields in headers.
+const headerSep = "-"
+
+// MarshalHeader returns the http.Header representation of v. It follows the same rules as Marshal,
+// except that keys are canonicalized as by textproto.CanonicalMIMEHeaderKey, inlined fields are
+// separated by "-" unless Encoder.SetSeparator is used, and slice fields without a custom encoding
+// are stored as multiple values of the same header.
+//
+// MarshalHeader is equivalent to calling MarshalHeader on a zero Encoder.
+func MarshalHeader(v interface{}) (http.Header, error) {
+	var enc Encoder
+	return enc.MarshalHeader(v)
+}
+
+// MarshalHeader works like the MarshalHeader function, but with the settings of e.
+func (e *Encoder) MarshalHeader(v interface{}) (http.Header, error) {
+	val, err := validValue(v)
+	if err != nil {
+		return nil, err
+	}
+	enc := *e
+	if !enc.hasSep {
+		enc.SetSeparator(headerSep)
+	}
+	ret := make(http.Header)
+	return ret, enc.marshal(headerSink(ret), "", val)
+}
+
+// UnmarshalHeader sets v's fields according to the headers contained by data.
+// It follows the same rules as Unmarshal, except that header names are matched
+// ignoring their case, inlined fields are separated by "-" unless Decoder.SetSeparator
+// is used, and slice fields without a custom decoding are filled with all the values
+// of their header. Other fields take the first value of their header.
+//
+// UnmarshalHeader is equivalent to calling UnmarshalHeader on a zero Decoder.
+func UnmarshalHeader(data http.Header, v interface{}) error {
+	var dec Decoder
+	return dec.UnmarshalHeader(data, v)
+}
+
+// UnmarshalHeader works like the UnmarshalHeader function, but with the settings of d.
+func (d *Decoder) UnmarshalHeader(data http.Header, v interface{}) error {
+	if data == nil {
+		return errIs("header passed", ErrNilValue)
+	}
+	val, err := ptrValidValue(v)
+	if err != nil {
+		return err
+	}
+	dec := *d
+	dec.foldCase = true
+	if !dec.hasSep {
+		dec.SetSeparator(headerSep)
+	}
+	return dec.unmarshal(headerSource(data), "", val)
+}
+
+// headerSink is the sink of MarshalHeader.
+type headerSink http.Header
+
+func (s headerSink) set(key, value string) { http.Header(s).Set(key, value) }
+
+func (s headerSink) add(key string, values []string) {
+	if len(values) > 0 {
+		s[textproto.CanonicalMIMEHeaderKey(key)] = values
+	}
+}
+
+// headerSource is the source of UnmarshalHeader.
+type headerSource http.Header
+
+func (s headerSource) get(key string) (string, bool) {
+	vals := s.values(key)
+	if len(vals) == 0 {
+		return "", false
+	}
+	return vals[0], true
+}
+
+func (s headerSource) getAll(key string) ([]string, bool) {
+	vals := s.values(key)
+	return vals, len(vals) > 0
+}
+
+func (s headerSource) rangeKeys(f func(key string)) {
+	for k, vals := range s {
+		if len(vals) > 0 {
+			f(k)
+		}
+	}
+}
+
+// values returns the values of key, which may be non-canonical
+// when it comes from a key of s itself.
+func (s headerSource) values(key string) []string {
+	if vals, ok := s[key]; ok {
+		return vals
+	}
+	return s[textproto.CanonicalMIMEHeaderKey(key)]
+}
@@ -0,0 +1,62 @@
+package redmap_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+type headerMetadata struct {
+	RequestID string   `redmap:"x-request-id"`
+	Forwarded []string `redmap:"x-forwarded-for"`
+	Client    struct {
+		Name    string `redmap:"name"`
+		Version int    `redmap:"version"`
+	} `redmap:"x-client,inline"`
+}
+
+func TestMarshalHeader(t *testing.T) {
+	var in headerMetadata
+	in.RequestID = "abc"
+	in.Forwarded = []string{"10.0.0.1", "10.0.0.2"}
+	in.Client.Name = "cli"
+	in.Client.Version = 3
+	expected := http.Header{
+		"X-Request-Id":     {"abc"},
+		"X-Forwarded-For":  {"10.0.0.1", "10.0.0.2"},
+		"X-Client-Name":    {"cli"},
+		"X-Client-Version": {"3"},
+	}
+	out, err := redmap.MarshalHeader(in)
+	if err != nil {
+		t.Fatalf("MarshalHeader returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("MarshalHeader's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestUnmarshalHeader(t *testing.T) {
+	in := make(http.Header)
+	in.Set("X-Request-ID", "abc")
+	in.Add("X-Forwarded-For", "10.0.0.1")
+	in.Add("X-Forwarded-For", "10.0.0.2")
+	in.Set("x-client-name", "cli")
+	in["x-client-version"] = []string{"3"} // Non-canonical key.
+	var expected headerMetadata
+	expected.RequestID = "abc"
+	expected.Forwarded = []string{"10.0.0.1", "10.0.0.2"}
+	expected.Client.Name = "cli"
+	expected.Client.Version = 3
+
+	var out headerMetadata
+	err := redmap.UnmarshalHeader(in, &out)
+	if err != nil {
+		t.Fatalf("UnmarshalHeader returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("UnmarshalHeader's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", in, expected, out)
+	}
+}
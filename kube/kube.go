@@ -0,0 +1,219 @@
+// Package kube converts structs to and from Kubernetes labels and annotations,
+// using the conversions of redmap and validating the syntax Kubernetes requires.
+//
+// Keys have an optional prefix, which must be a DNS subdomain, followed by a slash and a name.
+// The prefix is usually given by an inlined struct with the "/" separator:
+//
+//	type Config struct {
+//		Owner struct {
+//			Team string `redmap:"team"`
+//		} `redmap:"example.com,inline,sep=/"`
+//	}
+//
+// Marshaling a Config produces the key "example.com/team".
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/livingsilver94/redmap"
+)
+
+var (
+	// ErrInvalidKey is returned when a label or annotation key is malformed.
+	ErrInvalidKey = errors.New("not a valid key")
+	// ErrInvalidValue is returned when a label value is malformed.
+	ErrInvalidValue = errors.New("not a valid label value")
+	// ErrTooLarge is returned when annotations exceed the total size allowed by Kubernetes.
+	ErrTooLarge = errors.New("too large")
+)
+
+const (
+	// maxNameLen is the maximum length of a key's name and of a label value.
+	maxNameLen = 63
+	// maxPrefixLen is the maximum length of a key's prefix.
+	maxPrefixLen = 253
+	// maxAnnotationsSize is the maximum total size of the keys and values of annotations.
+	maxAnnotationsSize = 256 * 1024
+)
+
+// Encoder converts structs into labels and annotations.
+// The zero value is ready to use.
+type Encoder struct {
+	// Options marshals structs into maps, which are then validated.
+	Options redmap.Encoder
+}
+
+// MarshalLabels returns the labels representing v, as returned by redmap.Marshal.
+// An error wrapping ErrInvalidKey or ErrInvalidValue is returned if a key or
+// a value is not allowed in labels.
+func (e *Encoder) MarshalLabels(v interface{}) (map[string]string, error) {
+	ret, err := e.Options.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return ret, validateLabels(ret)
+}
+
+// MarshalAnnotations returns the annotations representing v, as returned by redmap.Marshal.
+// An error wrapping ErrInvalidKey is returned if a key is not allowed in annotations,
+// and an error wrapping ErrTooLarge if keys and values exceed 256 KiB in total.
+func (e *Encoder) MarshalAnnotations(v interface{}) (map[string]string, error) {
+	ret, err := e.Options.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return ret, validateAnnotations(ret)
+}
+
+// MarshalLabels returns the labels representing v. See Encoder.MarshalLabels for details.
+func MarshalLabels(v interface{}) (map[string]string, error) {
+	var enc Encoder
+	return enc.MarshalLabels(v)
+}
+
+// MarshalAnnotations returns the annotations representing v.
+// See Encoder.MarshalAnnotations for details.
+func MarshalAnnotations(v interface{}) (map[string]string, error) {
+	var enc Encoder
+	return enc.MarshalAnnotations(v)
+}
+
+// Decoder converts labels and annotations into structs.
+// The zero value is ready to use.
+type Decoder struct {
+	// Options unmarshals maps into structs, once they are validated.
+	Options redmap.Decoder
+}
+
+// UnmarshalLabels validates labels as in Encoder.MarshalLabels
+// and unmarshals them into v as in redmap.Unmarshal.
+func (d *Decoder) UnmarshalLabels(labels map[string]string, v interface{}) error {
+	if err := validateLabels(labels); err != nil {
+		return err
+	}
+	return d.Options.Unmarshal(labels, v)
+}
+
+// UnmarshalAnnotations validates annotations as in Encoder.MarshalAnnotations
+// and unmarshals them into v as in redmap.Unmarshal.
+func (d *Decoder) UnmarshalAnnotations(annotations map[string]string, v interface{}) error {
+	if err := validateAnnotations(annotations); err != nil {
+		return err
+	}
+	return d.Options.Unmarshal(annotations, v)
+}
+
+// UnmarshalLabels unmarshals labels into v. See Decoder.UnmarshalLabels for details.
+func UnmarshalLabels(labels map[string]string, v interface{}) error {
+	var dec Decoder
+	return dec.UnmarshalLabels(labels, v)
+}
+
+// UnmarshalAnnotations unmarshals annotations into v.
+// See Decoder.UnmarshalAnnotations for details.
+func UnmarshalAnnotations(annotations map[string]string, v interface{}) error {
+	var dec Decoder
+	return dec.UnmarshalAnnotations(annotations, v)
+}
+
+func validateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if err := validateKey(k); err != nil {
+			return err
+		}
+		if msg := checkName(v); v != "" && msg != "" {
+			return fmt.Errorf("value %q of label %q is %w: %s", v, k, ErrInvalidValue, msg)
+		}
+	}
+	return nil
+}
+
+func validateAnnotations(annotations map[string]string) error {
+	size := 0
+	for k, v := range annotations {
+		if err := validateKey(k); err != nil {
+			return err
+		}
+		size += len(k) + len(v)
+	}
+	if size > maxAnnotationsSize {
+		return fmt.Errorf("annotations of %d bytes are %w, the limit is %d", size, ErrTooLarge, maxAnnotationsSize)
+	}
+	return nil
+}
+
+// validateKey returns an error if key is not a valid label or annotation key.
+func validateKey(key string) error {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if msg := checkPrefix(prefix); msg != "" {
+			return fmt.Errorf("key %q is %w: prefix %s", key, ErrInvalidKey, msg)
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("key %q is %w: name must not be empty", key, ErrInvalidKey)
+	}
+	if msg := checkName(name); msg != "" {
+		return fmt.Errorf("key %q is %w: name %s", key, ErrInvalidKey, msg)
+	}
+	return nil
+}
+
+// checkName returns why name is not a valid key name or label value,
+// or the empty string if it is valid.
+func checkName(name string) string {
+	if len(name) > maxNameLen {
+		return fmt.Sprintf("must be no more than %d characters", maxNameLen)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if isAlphanumeric(c) {
+			continue
+		}
+		if c != '-' && c != '_' && c != '.' {
+			return fmt.Sprintf("must not contain %q", c)
+		}
+		if i == 0 || i == len(name)-1 {
+			return "must start and end with an alphanumeric character"
+		}
+	}
+	return ""
+}
+
+// checkPrefix returns why prefix is not a valid DNS subdomain,
+// or the empty string if it is valid.
+func checkPrefix(prefix string) string {
+	if prefix == "" {
+		return "must not be empty"
+	}
+	if len(prefix) > maxPrefixLen {
+		return fmt.Sprintf("must be no more than %d characters", maxPrefixLen)
+	}
+	for _, label := range strings.Split(prefix, ".") {
+		if label == "" {
+			return "must not contain empty DNS labels"
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+				continue
+			}
+			if c != '-' {
+				return fmt.Sprintf("must not contain %q", c)
+			}
+			if i == 0 || i == len(label)-1 {
+				return "must start and end each DNS label with a lowercase alphanumeric character"
+			}
+		}
+	}
+	return ""
+}
+
+func isAlphanumeric(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
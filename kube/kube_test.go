@@ -0,0 +1,105 @@
+package kube_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/livingsilver94/redmap/kube"
+)
+
+type config struct {
+	App   string `redmap:"app"`
+	Tier  string `redmap:"tier,omitempty"`
+	Owner struct {
+		Team     string `redmap:"team"`
+		Replicas int    `redmap:"replicas"`
+	} `redmap:"example.com,inline,sep=/"`
+}
+
+func TestMarshalLabels(t *testing.T) {
+	var in config
+	in.App = "web"
+	in.Owner.Team = "platform_core"
+	in.Owner.Replicas = 3
+	expected := map[string]string{
+		"app":                  "web",
+		"example.com/team":     "platform_core",
+		"example.com/replicas": "3",
+	}
+	out, err := kube.MarshalLabels(in)
+	if err != nil {
+		t.Fatalf("MarshalLabels returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("MarshalLabels's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+
+	var back config
+	err = kube.UnmarshalLabels(out, &back)
+	if err != nil {
+		t.Fatalf("UnmarshalLabels returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(back, in) {
+		t.Fatalf("UnmarshalLabels's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, back)
+	}
+}
+
+func TestLabelsInvalid(t *testing.T) {
+	tests := []struct {
+		In  map[string]string
+		Err error
+	}{
+		{In: map[string]string{"": "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{"example.com/": "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{"/name": "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{"Example.com/name": "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{"example..com/name": "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{"a/b/name": "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{"-name": "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{"na me": "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{strings.Repeat("n", 64): "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{strings.Repeat("p", 254) + "/name": "v"}, Err: kube.ErrInvalidKey},
+		{In: map[string]string{"app": "web "}, Err: kube.ErrInvalidValue},
+		{In: map[string]string{"app": "web."}, Err: kube.ErrInvalidValue},
+		{In: map[string]string{"app": strings.Repeat("v", 64)}, Err: kube.ErrInvalidValue},
+	}
+	for _, test := range tests {
+		var out config
+		if err := kube.UnmarshalLabels(test.In, &out); !errors.Is(err, test.Err) {
+			t.Fatalf("UnmarshalLabels of %q returned %v instead of %q", test.In, err, test.Err)
+		}
+	}
+
+	var in config
+	in.App = "has spaces"
+	if _, err := kube.MarshalLabels(in); !errors.Is(err, kube.ErrInvalidValue) {
+		t.Fatalf("MarshalLabels returned %v instead of %q", err, kube.ErrInvalidValue)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	in := struct {
+		Description string `redmap:"description"`
+		Config      struct {
+			Raw string `redmap:"raw"`
+		} `redmap:"config.example.com,inline,sep=/"`
+	}{Description: "Any text, even with spaces and symbols!"}
+	in.Config.Raw = `{"json": true}`
+	out, err := kube.MarshalAnnotations(in)
+	if err != nil {
+		t.Fatalf("MarshalAnnotations returned unexpected error %q", err)
+	}
+	if out["config.example.com/raw"] != in.Config.Raw || out["description"] != in.Description {
+		t.Fatalf("MarshalAnnotations returned unexpected annotations %q", out)
+	}
+
+	in.Description = strings.Repeat("x", 256*1024)
+	if _, err := kube.MarshalAnnotations(in); !errors.Is(err, kube.ErrTooLarge) {
+		t.Fatalf("MarshalAnnotations returned %v instead of %q", err, kube.ErrTooLarge)
+	}
+	if err := kube.UnmarshalAnnotations(map[string]string{"bad key": ""}, &in); !errors.Is(err, kube.ErrInvalidKey) {
+		t.Fatalf("UnmarshalAnnotations returned %v instead of %q", err, kube.ErrInvalidKey)
+	}
+}
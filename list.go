@@ -0,0 +1,131 @@
+package redmap
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultListSep is the delimiter of list elements when the `list` option has no value.
+const defaultListSep = ","
+
+// listDelimiter returns the rune delimiting list elements as requested by tags.
+func listDelimiter(tags structTags) (rune, error) {
+	sep := tags.listSep
+	if sep == "" {
+		sep = defaultListSep
+	}
+	r, size := utf8.DecodeRuneInString(sep)
+	if size != len(sep) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("invalid list delimiter %q", sep)
+	}
+	return r, nil
+}
+
+// sliceToString encodes the elements of slice into a single string delimited as requested by tags.
+// Elements containing the delimiter or quotes are quoted as in CSV.
+func (e *Encoder) sliceToString(slice reflect.Value, tags structTags) (string, error) {
+	if slice.Kind() != reflect.Slice {
+		return "", fmt.Errorf("%s is not a slice and cannot be a list", slice.Type())
+	}
+	delim, err := listDelimiter(tags)
+	if err != nil {
+		return "", err
+	}
+	record, err := e.sliceToStrings(slice, tags)
+	if err != nil {
+		return "", err
+	}
+	if len(record) == 1 && record[0] == "" {
+		// The CSV writer would write nothing, which is indistinguishable from an empty slice.
+		return `""`, nil
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = delim
+	if err := w.Write(record); err != nil {
+		return "", err
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// sliceToStrings encodes each element of slice.
+func (e *Encoder) sliceToStrings(slice reflect.Value, tags structTags) ([]string, error) {
+	elemTags := tags
+	elemTags.list = false
+	strs := make([]string, slice.Len())
+	for i := range strs {
+		elem := slice.Index(i)
+		for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Ptr && e.hasNull {
+			strs[i] = e.null
+			continue
+		}
+		var err error
+		strs[i], err = e.fieldToString(elem, elemTags)
+		if err != nil {
+			return nil, err
+		}
+		if slice.Index(i).Kind() == reflect.Ptr && e.isNull(strs[i]) {
+			return nil, errIs(fmt.Sprintf("element %d of %s", i, slice.Type()), ErrAmbiguousNull)
+		}
+	}
+	return strs, nil
+}
+
+// stringToSlice decodes a string encoded by sliceToString into slice.
+func (d *decodeState) stringToSlice(str string, slice reflect.Value, tags structTags) error {
+	if slice.Kind() != reflect.Slice {
+		return fmt.Errorf("%s is not a slice and cannot be a list", slice.Type())
+	}
+	delim, err := listDelimiter(tags)
+	if err != nil {
+		return err
+	}
+	if str == "" {
+		slice.Set(reflect.Zero(slice.Type()))
+		return nil
+	}
+	r := csv.NewReader(strings.NewReader(str))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) != 1 {
+		return fmt.Errorf("list %q contains unquoted line breaks", str)
+	}
+	return d.stringsToSlice(records[0], slice, tags)
+}
+
+// stringsToSlice decodes each of strs into an element of slice.
+func (d *decodeState) stringsToSlice(strs []string, slice reflect.Value, tags structTags) error {
+	elemTags := tags
+	elemTags.list = false
+	ret := reflect.MakeSlice(slice.Type(), len(strs), len(strs))
+	for i, str := range strs {
+		elem := ret.Index(i)
+		if elem.Kind() == reflect.Ptr && d.isNull(str) {
+			continue
+		}
+		elem, err := allocPointers(elem)
+		if err != nil {
+			return err
+		}
+		if err := d.stringToField(str, elem, elemTags); err != nil {
+			return err
+		}
+	}
+	slice.Set(ret)
+	return nil
+}
@@ -0,0 +1,108 @@
+package redmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+func TestListRoundTrip(t *testing.T) {
+	type stru struct {
+		Strings []string `redmap:"strings,list=,"`
+		Ints    []int    `redmap:"ints,list"`
+		Semi    []string `redmap:"semi,list=;"`
+		Single  []string `redmap:"single,list"`
+		Empty   []string `redmap:"empty,list"`
+	}
+	tests := []struct {
+		In  stru
+		Out map[string]string
+	}{
+		{
+			In: stru{
+				Strings: []string{"a", "b,c", `d"e`, " f"},
+				Ints:    []int{1, 2, 3},
+				Semi:    []string{"a,b", "c;d"},
+				Single:  []string{""},
+			},
+			Out: map[string]string{
+				"strings": `a,"b,c","d""e"," f"`,
+				"ints":    "1,2,3",
+				"semi":    `a,b;"c;d"`,
+				"single":  `""`,
+				"empty":   "",
+			},
+		},
+	}
+	for _, test := range tests {
+		mp, err := redmap.Marshal(test.In)
+		if err != nil {
+			t.Fatalf("Marshal returned unexpected error %q", err)
+		}
+		if !reflect.DeepEqual(mp, test.Out) {
+			t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", test.Out, mp)
+		}
+		var out stru
+		err = redmap.Unmarshal(mp, &out)
+		if err != nil {
+			t.Fatalf("Unmarshal returned unexpected error %q", err)
+		}
+		if !reflect.DeepEqual(out, test.In) {
+			t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", test.In, out)
+		}
+	}
+}
+
+func TestListPointers(t *testing.T) {
+	type stru struct {
+		Ptrs []*int `redmap:"ptrs,list"`
+	}
+	five, seven := 5, 7
+	in := stru{Ptrs: []*int{&five, &seven, nil}}
+	expected := map[string]string{"ptrs": "5,7,null"}
+	var enc redmap.Encoder
+	enc.SetNull("null")
+	mp, err := enc.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, mp)
+	}
+	var dec redmap.Decoder
+	dec.SetNull("null")
+	var out stru
+	err = dec.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, out)
+	}
+}
+
+func TestListInvalid(t *testing.T) {
+	tests := []interface{}{
+		// Not a slice.
+		&struct {
+			V string `redmap:",list"`
+		}{},
+		// Delimiter is not a single character.
+		&struct {
+			V []string `redmap:",list=::"`
+		}{},
+		// Delimiter is a quote.
+		&struct {
+			V []string `redmap:",list=\""`
+		}{},
+	}
+	for _, test := range tests {
+		if _, err := redmap.Marshal(test); err == nil {
+			t.Fatalf("Marshal of %T did not return error", test)
+		}
+		if err := redmap.Unmarshal(map[string]string{"V": "a"}, test); err == nil {
+			t.Fatalf("Unmarshal of %T did not return error", test)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package logfmt reads and writes structs as logfmt lines, using the conversions of redmap.
+//
+// A logfmt line is a sequence of key=value pairs separated by spaces, such as:
+//
+//	name=redmap stars=42 description="a quoted value"
+//
+// Values containing spaces, quotes, "=" or non-printable characters are quoted
+// using Go escape sequences. A key without "=" has an empty value.
+package logfmt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/livingsilver94/redmap"
+)
+
+// Encoder writes structs as logfmt lines to an output stream.
+type Encoder struct {
+	// Options sets the tag keys, number formats and other settings used to marshal each line.
+	Options redmap.Encoder
+	w       io.Writer
+}
+
+// NewEncoder returns a new Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes v to the stream as a logfmt line, followed by a newline.
+// Pairs are written in the order returned by redmap.Encoder.MarshalOrdered.
+func (e *Encoder) Encode(v interface{}) error {
+	line, err := e.marshal(v)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	_, err = e.w.Write(line)
+	return err
+}
+
+func (e *Encoder) marshal(v interface{}) ([]byte, error) {
+	pairs, err := e.Options.MarshalOrdered(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for i, pair := range pairs {
+		if !validKey(pair.Key) {
+			return nil, fmt.Errorf("invalid key %q", pair.Key)
+		}
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(pair.Key)
+		buf.WriteByte('=')
+		if needsQuotes(pair.Value) {
+			buf.WriteString(strconv.Quote(pair.Value))
+		} else {
+			buf.WriteString(pair.Value)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Marshal returns v as a logfmt line, without a trailing newline.
+func Marshal(v interface{}) ([]byte, error) {
+	var enc Encoder
+	return enc.marshal(v)
+}
+
+// Decoder reads structs from logfmt lines in an input stream.
+type Decoder struct {
+	// Options sets the case sensitivity, boolean spellings and other settings used to unmarshal each line.
+	Options redmap.Decoder
+	r       *bufio.Reader
+}
+
+// NewDecoder returns a new Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next non-blank line from the stream and unmarshals it into v,
+// which must be a pointer as in redmap.Unmarshal. At the end of the stream, Decode returns io.EOF.
+func (d *Decoder) Decode(v interface{}) error {
+	for {
+		line, err := d.r.ReadString('\n')
+		if strings.TrimSpace(line) != "" {
+			mp, perr := Read(line)
+			if perr != nil {
+				return perr
+			}
+			return d.Options.Unmarshal(mp, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Unmarshal parses a logfmt line and unmarshals it into v, which must be a pointer as in redmap.Unmarshal.
+func Unmarshal(line []byte, v interface{}) error {
+	mp, err := Read(string(line))
+	if err != nil {
+		return err
+	}
+	return redmap.Unmarshal(mp, v)
+}
+
+// Read parses a logfmt line into a map. If a key appears more than once, the last value wins.
+func Read(line string) (map[string]string, error) {
+	ret := make(map[string]string)
+	for i := 0; ; {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return ret, nil
+		}
+		start := i
+		for i < len(line) && !isSpace(line[i]) && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		key := line[start:i]
+		if key == "" {
+			return nil, fmt.Errorf("missing key at column %d", start+1)
+		}
+		if i == len(line) || line[i] != '=' {
+			if i < len(line) && line[i] == '"' {
+				return nil, fmt.Errorf("unexpected quote in key at column %d", i+1)
+			}
+			ret[key] = ""
+			continue
+		}
+		i++ // Skip "=".
+		if i < len(line) && line[i] == '"' {
+			end, err := closingQuote(line, i)
+			if err != nil {
+				return nil, err
+			}
+			value, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value at column %d: %w", i+1, err)
+			}
+			ret[key] = value
+			i = end + 1
+			if i < len(line) && !isSpace(line[i]) {
+				return nil, fmt.Errorf("unexpected %q after quoted value at column %d", line[i], i+1)
+			}
+			continue
+		}
+		start = i
+		for i < len(line) && !isSpace(line[i]) {
+			i++
+		}
+		ret[key] = line[start:i]
+	}
+}
+
+// closingQuote returns the index of the quote closing the one at index start.
+func closingQuote(line string, start int) (int, error) {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated quoted value at column %d", start+1)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// validKey reports whether key can be written as a logfmt key.
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// needsQuotes reports whether value must be quoted to be written as a logfmt value.
+func needsQuotes(value string) bool {
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package logfmt_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/livingsilver94/redmap/logfmt"
+)
+
+type record struct {
+	Level   string `redmap:"level"`
+	Message string `redmap:"msg"`
+	Count   int    `redmap:"count"`
+	Empty   string `redmap:"empty"`
+	User    struct {
+		ID   int    `redmap:"id"`
+		Name string `redmap:"name"`
+	} `redmap:"user,inline"`
+}
+
+func TestMarshal(t *testing.T) {
+	var in record
+	in.Level = "info"
+	in.Message = `user said "hi"=hello`
+	in.Count = 3
+	in.User.ID = 7
+	in.User.Name = "Zoë"
+	expected := `level=info msg="user said \"hi\"=hello" count=3 empty= user.id=7 user.name=Zoë`
+	out, err := logfmt.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if string(out) != expected {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %s\n\tOut: %s", expected, out)
+	}
+
+	var back record
+	err = logfmt.Unmarshal(out, &back)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(back, in) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, back)
+	}
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	records := []record{{Level: "info", Count: 1}, {Level: "warn", Message: "multi\nline"}}
+	var buf bytes.Buffer
+	enc := logfmt.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			t.Fatalf("Encode returned unexpected error %q", err)
+		}
+	}
+	dec := logfmt.NewDecoder(&buf)
+	for _, expected := range records {
+		var out record
+		if err := dec.Decode(&out); err != nil {
+			t.Fatalf("Decode returned unexpected error %q", err)
+		}
+		if !reflect.DeepEqual(out, expected) {
+			t.Fatalf("Decode's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+		}
+	}
+	if err := dec.Decode(&record{}); err != io.EOF {
+		t.Fatalf("Decode returned %v instead of io.EOF at the end of the stream", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		In  string
+		Out map[string]string
+	}{
+		{In: "a=1 b=two", Out: map[string]string{"a": "1", "b": "two"}},
+		{In: "  a=1\tb=\"x y\"  ", Out: map[string]string{"a": "1", "b": "x y"}},
+		{In: "flag a= b=\"\"", Out: map[string]string{"flag": "", "a": "", "b": ""}},
+		{In: `a="escaped \"quote\" \\ \n"`, Out: map[string]string{"a": "escaped \"quote\" \\ \n"}},
+		{In: "a=1 a=2", Out: map[string]string{"a": "2"}},
+	}
+	for _, test := range tests {
+		out, err := logfmt.Read(test.In)
+		if err != nil {
+			t.Fatalf("Read of %q returned unexpected error %q", test.In, err)
+		}
+		if !reflect.DeepEqual(out, test.Out) {
+			t.Fatalf("Read's output doesn't match the expected value\n\tIn: %q\n\tExpected: %q\n\tOut: %q", test.In, test.Out, out)
+		}
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []string{
+		"=value",
+		`a="unterminated`,
+		`a="quoted"garbage`,
+		`a"b=1`,
+	}
+	for _, test := range tests {
+		if _, err := logfmt.Read(test); err == nil {
+			t.Fatalf("Read of %q did not return error", test)
+		}
+	}
+	type invalidKey struct {
+		V string `redmap:"a key"`
+	}
+	if _, err := logfmt.Marshal(invalidKey{}); err == nil || !strings.Contains(err.Error(), "invalid key") {
+		t.Fatalf("Marshal with an invalid key returned %v", err)
+	}
+}
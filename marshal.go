@@ -4,6 +4,7 @@ import (
 	"encoding"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 )
 
@@ -21,15 +22,21 @@ type StringMapMarshaler interface {
 //
 // Marshal converts all fields with built-in types except arrays, functions and channels, plus
 // structs implementing encoding.TextMarshaler or fmt.Stringer, checked in this exact order.
+// Implementations with a pointer receiver are honored too, as Unmarshal does.
+// Numbers are written in base 10 and without exponent, unless Encoder.SetIntBase or
+// Encoder.SetFloatFormat are used, or the field's format string says otherwise.
+// Codecs registered with RegisterCodec or Encoder.UseCodec take precedence over all of the above.
 // If a field is a pointer to a supported type, the underlying type's value is marshaled.
 // If the pointer is nil, it is marshaled as it had the underlying type's zero value unless `omitempty`
-// is specified.
+// is specified or a null representation is set with Encoder.SetNull, in which case
+// a non-nil pointer written as the null representation makes Marshal return ErrAmbiguousNull.
+// Nil pointers to inlined structs are skipped, unless a null representation is set.
 //
 // The encoding of each struct field can be customized by the format string stored under the "redmap"
 // key in the struct field's tag. The format string gives the name of the field, possibly followed by
 // a comma-separated list of options. The name may be empty in order to specify options without
 // overriding the default field name. If the format string is equal to "-", the struct field
-// is excluded from marshaling.
+// is excluded from marshaling. Encoder.SetTagKeys allows falling back to other keys, such as "json".
 //
 // Examples of struct field tags and their meanings:
 //
@@ -45,6 +52,10 @@ type StringMapMarshaler interface {
 //   // the field is skipped if zero. Note the leading comma.
 //   Field int `redmap:",omitempty"`
 //
+//   // Field is skipped if zero, just like omitempty. Unlike omitempty,
+//   // omitzero never affects unmarshaling.
+//   Field int `redmap:",omitzero"`
+//
 //   // Field is ignored by this package.
 //   Field int `redmap:"-"`
 //
@@ -55,13 +66,164 @@ type StringMapMarshaler interface {
 //   // The resulting map is added to the final map with keys flattened,
 //   // constructed in the "customName.subKeyName" format.
 //   Field int `redmap:"customName,inline"`
+//
+//   // Like the above, but keys are constructed in the "customName:subKeyName" format.
+//   // Any string can be used as separator, including the empty string
+//   // and a comma, as in `redmap:"customName,inline,sep=,"`.
+//   Field int `redmap:"customName,inline,sep=:"`
+//
+//   // Like the above, but the resulting map is added to the final map
+//   // with no prefix at all, i.e. keys are just "subKeyName".
+//   Field int `redmap:",inline,noprefix"`
+//
+//   // Field appears in the map as key "customName", and its value is
+//   // serialized as a whole by encoding/json, or by the Serializer set
+//   // with Encoder.SetSerializer. Any type supported by the Serializer can be used.
+//   Field []int `redmap:"customName,json"`
+//
+//   // Field appears in the map as key "customName", and its elements
+//   // are stored in a single value delimited by a comma, e.g. "a,b,c".
+//   // Elements containing the delimiter or quotes are quoted as in CSV.
+//   // The delimiter can be any single character, e.g. `redmap:"customName,list=;"`,
+//   // and defaults to a comma. Elements can have any type supported by Marshal.
+//   Field []string `redmap:"customName,list=,"`
+//
+//   // Field is written in base 16 with the "0x" prefix. The base can be
+//   // a number between 2 and 36, or "bin", "oct" and "hex" (no prefix),
+//   // or "0b", "0o" and "0x" (with prefix).
+//   Field int `redmap:"customName,base=0x"`
+//
+//   // Field is written with format 'e' and 3 decimal digits,
+//   // with the same meaning as strconv.FormatFloat.
+//   Field float64 `redmap:"customName,fmt=e,prec=3"`
+//
+//   // Field must be an interface holding a type registered with RegisterType.
+//   // The concrete value is inlined, and the name of its type is added
+//   // to the final map as key "customName.kind".
+//   Field Shape `redmap:"customName,inline,typekey=kind"`
+//
+//   // Field is converted by the codec registered under the name "unixms"
+//   // with RegisterNamedCodec, which must convert values of type time.Time.
+//   Field time.Time `redmap:"customName,codec=unixms"`
+//
+//   // Field is described by "some text, with commas" when bound to a flag
+//   // by BindFlags. The usage extends to the end of the format string.
+//   Field int `redmap:"customName,usage=some text, with commas"`
+//
+// Inlined structs referencing themselves through pointers make Marshal return ErrCycle,
+// and inlined structs nested deeper than the limit set with Encoder.SetMaxDepth make it
+// return ErrMaxDepth.
+//
+// Marshal is equivalent to calling Marshal on a zero Encoder.
 func Marshal(v interface{}) (map[string]string, error) {
+	var enc Encoder
+	return enc.Marshal(v)
+}
+
+// Encoder marshals Go values into maps of strings. The zero value is ready to use
+// and behaves like the Marshal function. Its methods customize the encoding and
+// must not be called concurrently with Marshal.
+type Encoder struct {
+	config
+	boolTrue  string
+	boolFalse string
+}
+
+// SetBoolFormat sets the strings that booleans are written as, for example "1" and "0".
+// By default, booleans are written as "true" and "false".
+func (e *Encoder) SetBoolFormat(trueStr, falseStr string) {
+	e.boolTrue, e.boolFalse = trueStr, falseStr
+}
+
+// Marshal works like the Marshal function, but with the settings of e.
+func (e *Encoder) Marshal(v interface{}) (map[string]string, error) {
 	val, err := validValue(v)
 	if err != nil {
 		return nil, err
 	}
-	ret := make(map[string]string)
-	return ret, marshalRecursive(ret, "", val)
+	ret := make(mapSink)
+	return ret, e.marshal(ret, "", val)
+}
+
+// marshal marshals val into out, prepending prefix to all keys.
+func (e *Encoder) marshal(out sink, prefix string, val reflect.Value) error {
+	state := encodeState{Encoder: e, out: out}
+	return state.marshalRecursive(prefix, val)
+}
+
+// sink receives the key-value pairs produced by marshaling.
+type sink interface {
+	set(key, value string)
+}
+
+// multiSink is a sink that accepts more than one value per key.
+// Marshaling into a multiSink stores slices as multiple values.
+type multiSink interface {
+	sink
+	add(key string, values []string)
+}
+
+// groupSink is a sink that stores the fields of inlined structs into nested groups,
+// rather than prepending a prefix to their keys.
+type groupSink interface {
+	sink
+	// group returns the sink of the group called name, creating it if necessary.
+	group(name string) sink
+}
+
+// nativeSink is a sink that accepts booleans and numbers without converting them to strings.
+type nativeSink interface {
+	sink
+	setNative(key string, value interface{})
+}
+
+// fieldSink is a sink that also receives the struct field each value comes from.
+type fieldSink interface {
+	sink
+	setField(key, str string, value reflect.Value, tags structTags)
+}
+
+// exhaustiveSink is a sink that receives every field, ignoring `omitempty` and `omitzero`
+// and walking nil pointers to inlined structs as if they pointed to a zero value.
+type exhaustiveSink interface {
+	sink
+	exhaustive()
+}
+
+// orderedKeysSink is a sink that keeps the order of keys,
+// so the keys of StringMapMarshaler implementations are sorted before being set.
+type orderedKeysSink interface {
+	sink
+	orderedKeys()
+}
+
+// stringMapSink is a sink that receives the maps returned by StringMapMarshaler as a whole.
+type stringMapSink interface {
+	sink
+	// setMap sets the keys of mp, prepending prefix to them.
+	setMap(prefix string, mp map[string]string)
+}
+
+// mapSink is the sink of Marshal.
+type mapSink map[string]string
+
+func (s mapSink) set(key, value string) { s[key] = value }
+
+// encodeState holds the state of a single Marshal call.
+type encodeState struct {
+	*Encoder
+	// out receives the marshal result.
+	out sink
+	// depth is the number of nested structs being marshaled.
+	depth int
+	// ptrSeen holds the pointers being dereferenced to reach the struct currently marshaled.
+	ptrSeen map[pointer]struct{}
+}
+
+// pointer identifies a pointer value.
+type pointer struct {
+	addr uintptr
+	typ  reflect.Type
 }
 
 func validValue(v interface{}) (reflect.Value, error) {
@@ -77,15 +239,18 @@ func validValue(v interface{}) (reflect.Value, error) {
 	return val, nil
 }
 
-// marshalRecursive marshal a struct represented by val into a map[string]string.
-// Given its recursive nature, it needs to remember the intermediate results:
-// mp is the temporary marshal result; prefix is the prefix applied to a field
-// name in case of an inlined inner struct.
-func marshalRecursive(mp map[string]string, prefix string, stru reflect.Value) error {
-	typ := stru.Type()
-	if typ.Implements(mapMarshalerType) {
-		return structToMap(mp, prefix, stru)
+// marshalRecursive marshal a struct represented by val into e.out.
+// prefix is the prefix applied to a field name in case of an inlined inner struct.
+func (e *encodeState) marshalRecursive(prefix string, stru reflect.Value) error {
+	e.depth++
+	defer func() { e.depth-- }()
+	if e.depth > e.maxDepthOrDefault() {
+		return errIs(fmt.Sprintf("value at key prefix %q", prefix), ErrMaxDepth)
+	}
+	if impl, ok := implementor(stru, mapMarshalerType); ok {
+		return e.structToMap(prefix, impl)
 	}
+	typ := stru.Type()
 	if stru.Kind() != reflect.Struct {
 		return errIs(stru.Type(), ErrNoCodec)
 	}
@@ -96,81 +261,303 @@ func marshalRecursive(mp map[string]string, prefix string, stru reflect.Value) e
 			// TODO: In Go 1.17, use field.IsExported().
 			continue
 		}
-		tags := redmapTags(field.Tag)
+		tags := redmapTags(field.Tag, e.tagKeys)
 		value := stru.Field(i)
-		if tags.ignored || (tags.omitempty && value.IsZero()) {
+		if tags.ignored || ((tags.omitempty || tags.omitzero) && value.IsZero() && !e.exhaustive()) {
 			continue
 		}
 		if tags.name == "" {
 			tags.name = field.Name
 		}
-
-		for value.Kind() == reflect.Ptr && !value.IsNil() {
-			value = value.Elem()
-		}
+		tags.name = e.keyName(tags.name)
 
 		if tags.inline {
-			err := marshalRecursive(mp, prefix+tags.name+inlineSep, value)
+			var err error
+			if tags.typekey != "" {
+				err = e.marshalTyped(prefix, value, tags)
+			} else {
+				err = e.marshalInline(prefix, value, tags)
+			}
 			if err != nil {
 				return err
 			}
-		} else {
-			str, err := fieldToString(value)
+			continue
+		}
+
+		isPtr := value.Kind() == reflect.Ptr
+		for value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Ptr && e.hasNull {
+			e.setField(prefix+tags.name, e.null, value, tags)
+			continue
+		}
+		if multi, ok := e.out.(multiSink); ok && e.isMultiValue(value, tags) {
+			if value.Kind() == reflect.Ptr {
+				// A nil pointer to a slice has no values.
+				continue
+			}
+			strs, err := e.sliceToStrings(value, tags)
 			if err != nil {
 				return err
 			}
-			mp[prefix+tags.name] = str
+			multi.add(prefix+tags.name, strs)
+			continue
+		}
+		if native, ok := e.out.(nativeSink); ok {
+			if v, ok := e.nativeValue(value, tags); ok {
+				native.setNative(prefix+tags.name, v)
+				continue
+			}
+		}
+		str, err := e.fieldToString(value, tags)
+		if err != nil {
+			return err
 		}
+		if isPtr && e.isNull(str) {
+			return errIs(fmt.Sprintf("value at key %q", prefix+tags.name), ErrAmbiguousNull)
+		}
+		e.setField(prefix+tags.name, str, value, tags)
 	}
 	return nil
 }
 
-func structToMap(mp map[string]string, prefix string, stru reflect.Value) error {
+// setField sets key to str in e.out, str being the representation of value,
+// a field having tags.
+func (e *encodeState) setField(key, str string, value reflect.Value, tags structTags) {
+	if out, ok := e.out.(fieldSink); ok {
+		out.setField(key, str, value, tags)
+		return
+	}
+	e.out.set(key, str)
+}
+
+// marshalInline marshals value as an inlined struct, dereferencing pointers
+// and detecting reference cycles along the way.
+func (e *encodeState) marshalInline(prefix string, value reflect.Value, tags structTags) error {
+	for value.Kind() == reflect.Ptr {
+		// Nil pointers are walked through a zero value if e.out is exhaustive,
+		// in which case their address is zero.
+		ptr := pointer{typ: value.Type()}
+		if !value.IsNil() {
+			ptr.addr = value.Pointer()
+		} else if !e.exhaustive() {
+			break
+		}
+		if _, seen := e.ptrSeen[ptr]; seen {
+			if ptr.addr == 0 {
+				// A zero value of the same type is already being walked.
+				return nil
+			}
+			return errIs(fmt.Sprintf("value at key %q", prefix+tags.name), ErrCycle)
+		}
+		if e.ptrSeen == nil {
+			e.ptrSeen = make(map[pointer]struct{})
+		}
+		e.ptrSeen[ptr] = struct{}{}
+		defer delete(e.ptrSeen, ptr)
+		if value.IsNil() {
+			value = reflect.Zero(value.Type().Elem())
+		} else {
+			value = value.Elem()
+		}
+	}
+	if value.Kind() == reflect.Ptr {
+		// A nil pointer, as the others were dereferenced already.
+		// It has no fields to marshal, just like Unmarshal leaves it nil when no key has its prefix.
+		if e.hasNull {
+			e.out.set(prefix+tags.name, e.null)
+		}
+		return nil
+	}
+	return e.inline(prefix, tags, func(prefix string) error {
+		return e.marshalRecursive(prefix, value)
+	})
+}
+
+// exhaustive reports whether e.out is an exhaustiveSink.
+func (e *encodeState) exhaustive() bool {
+	_, ok := e.out.(exhaustiveSink)
+	return ok
+}
+
+// inline calls marshal with the key prefix of the fields of an inlined struct having tags.
+// If e.out is a groupSink, marshal is called with an empty prefix while e.out
+// is temporarily replaced by the group named after the field.
+func (e *encodeState) inline(prefix string, tags structTags, marshal func(prefix string) error) error {
+	grouper, ok := e.out.(groupSink)
+	if !ok || tags.noprefix {
+		return marshal(e.inlinePrefix(prefix, tags))
+	}
+	parent := e.out
+	e.out = grouper.group(tags.name)
+	defer func() { e.out = parent }()
+	return marshal("")
+}
+
+func (e *encodeState) structToMap(prefix string, stru reflect.Value) error {
 	conv, err := stru.Interface().(StringMapMarshaler).MarshalStringMap()
 	if err != nil {
 		return err
 	}
+	if out, ok := e.out.(stringMapSink); ok {
+		out.setMap(prefix, conv)
+		return nil
+	}
+	if _, ok := e.out.(orderedKeysSink); ok {
+		// Maps have no order, so sort keys to be deterministic.
+		keys := make([]string, 0, len(conv))
+		for k := range conv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e.out.set(prefix+k, conv[k])
+		}
+		return nil
+	}
 	for k, v := range conv {
-		mp[prefix+k] = v
+		e.out.set(prefix+k, v)
 	}
 	return nil
 }
 
-func fieldToString(val reflect.Value) (string, error) {
+func (e *Encoder) fieldToString(val reflect.Value, tags structTags) (string, error) {
+	if tags.json {
+		data, err := e.serializerOrDefault().Marshal(val.Interface())
+		return string(data), err
+	}
 	for val.Kind() == reflect.Ptr {
 		underlying := reflect.TypeOf(val.Interface()).Elem()
 		val = reflect.New(underlying).Elem()
 	}
-	typ := val.Type()
-	if typ.Implements(textMarshalerType) {
-		str, err := val.Interface().(encoding.TextMarshaler).MarshalText()
+	if tags.list {
+		return e.sliceToString(val, tags)
+	}
+	encode, ok, err := e.encoderFor(val.Type(), tags)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return encode(val)
+	}
+	if impl, ok := implementor(val, textMarshalerType); ok {
+		str, err := impl.Interface().(encoding.TextMarshaler).MarshalText()
 		return string(str), err
 	}
-	if typ.Implements(stringerType) {
-		return val.Interface().(fmt.Stringer).String(), nil
+	if impl, ok := implementor(val, stringerType); ok {
+		return impl.Interface().(fmt.Stringer).String(), nil
+	}
+
+	f := defaultNumberFormat
+	if kind := val.Kind(); reflect.Int <= kind && kind <= reflect.Complex128 { // Any numeric kind.
+		if f, err = e.numberFormat(tags); err != nil {
+			return "", err
+		}
 	}
 
 	switch val.Kind() {
 	case reflect.Bool:
-		return strconv.FormatBool(val.Bool()), nil
+		return e.formatBool(val.Bool()), nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return strconv.FormatInt(val.Int(), 10), nil
+		return f.formatInt(val.Int()), nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		return strconv.FormatUint(val.Uint(), 10), nil
+		return f.formatUint(val.Uint()), nil
 	case reflect.Float32:
-		return strconv.FormatFloat(val.Float(), 'f', -1, 32), nil
+		return strconv.FormatFloat(val.Float(), f.floatFmt, f.floatPrec, 32), nil
 	case reflect.Float64:
-		return strconv.FormatFloat(val.Float(), 'f', -1, 64), nil
+		return strconv.FormatFloat(val.Float(), f.floatFmt, f.floatPrec, 64), nil
 	case reflect.Complex64:
-		return strconv.FormatComplex(val.Complex(), 'f', -1, 64), nil
+		return strconv.FormatComplex(val.Complex(), f.floatFmt, f.floatPrec, 64), nil
 	case reflect.Complex128:
-		return strconv.FormatComplex(val.Complex(), 'f', -1, 128), nil
+		return strconv.FormatComplex(val.Complex(), f.floatFmt, f.floatPrec, 128), nil
 	case reflect.String:
 		return val.String(), nil
 	}
 	return "", fmt.Errorf("%s doesn't implement TextMarshaler or Stringer", val.Type())
 }
 
+// nativeValue returns val as a bool, an int64, a uint64 or a float64,
+// and whether it was possible, i.e. val has a matching kind and no custom encoding.
+func (e *Encoder) nativeValue(val reflect.Value, tags structTags) (interface{}, bool) {
+	if tags.json || tags.list || tags.codec != "" || tags.base != "" || tags.floatFmt != "" || tags.floatPrec != "" {
+		return nil, false
+	}
+	for val.Kind() == reflect.Ptr {
+		// A nil pointer, as the others were dereferenced already.
+		val = reflect.Zero(val.Type().Elem())
+	}
+	if _, ok := e.typeEncoder(val.Type()); ok {
+		return nil, false
+	}
+	_, isText := implementor(val, textMarshalerType)
+	_, isStringer := implementor(val, stringerType)
+	if isText || isStringer {
+		return nil, false
+	}
+	switch val.Kind() {
+	case reflect.Bool:
+		return val.Bool(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return val.Uint(), true
+	case reflect.Float32:
+		// Widening would expose the imprecision of float32, e.g. 0.1 would become 0.10000000149011612.
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(val.Float(), 'g', -1, 32), 64)
+		return f, true
+	case reflect.Float64:
+		return val.Float(), true
+	}
+	return nil, false
+}
+
+// isMultiValue reports whether val is a slice, or a nil pointer to one,
+// to be stored as multiple values, i.e. it doesn't have any custom encoding.
+func (e *Encoder) isMultiValue(val reflect.Value, tags structTags) bool {
+	for val.Kind() == reflect.Ptr {
+		val = reflect.Zero(val.Type().Elem())
+	}
+	if val.Kind() != reflect.Slice || tags.json || tags.list {
+		return false
+	}
+	if _, ok, _ := e.encoderFor(val.Type(), tags); ok {
+		return false
+	}
+	_, isText := implementor(val, textMarshalerType)
+	_, isStringer := implementor(val, stringerType)
+	return !isText && !isStringer
+}
+
+func (e *Encoder) formatBool(b bool) string {
+	if e.boolTrue == "" && e.boolFalse == "" {
+		return strconv.FormatBool(b)
+	}
+	if b {
+		return e.boolTrue
+	}
+	return e.boolFalse
+}
+
+// implementor returns a value implementing iface out of val, and whether it exists.
+// If val does not implement iface but a pointer to it does, a pointer to val is returned,
+// or a pointer to a copy of it if val is not addressable.
+func implementor(val reflect.Value, iface reflect.Type) (reflect.Value, bool) {
+	typ := val.Type()
+	if typ.Implements(iface) {
+		return val, true
+	}
+	if typ.Kind() == reflect.Interface || !reflect.PtrTo(typ).Implements(iface) {
+		return val, false
+	}
+	if val.CanAddr() {
+		return val.Addr(), true
+	}
+	ptr := reflect.New(typ)
+	ptr.Elem().Set(val)
+	return ptr, true
+}
+
 var (
 	mapMarshalerType  = reflect.TypeOf(new(StringMapMarshaler)).Elem()
 	stringerType      = reflect.TypeOf(new(fmt.Stringer)).Elem()
@@ -51,6 +51,23 @@ func (s stubIntMapMarshaler) MarshalStringMap() (map[string]string, error) {
 	return mapMarshalerOut, nil
 }
 
+// stubPtrStringer implements the fmt.Stringer interface with a pointer receiver.
+type stubPtrStringer struct{}
+
+func (s *stubPtrStringer) String() string { return stringerOut }
+
+// stubPtrTextMarshaler implements the encoding.TextMarshaler interface with a pointer receiver.
+type stubPtrTextMarshaler struct{}
+
+func (s *stubPtrTextMarshaler) MarshalText() ([]byte, error) { return []byte(textMarshalerOut), nil }
+
+// stubPtrMapMarshaler implements the redmap.StringMapMarshaler interface with a pointer receiver.
+type stubPtrMapMarshaler struct{}
+
+func (s *stubPtrMapMarshaler) MarshalStringMap() (map[string]string, error) {
+	return mapMarshalerOut, nil
+}
+
 func TestMarshalValidType(t *testing.T) {
 	var (
 		stub stubStringer = stubStringer{}
@@ -269,3 +286,229 @@ func TestMarshalNilField(t *testing.T) {
 		t.Fatalf("Marshal's output doesn't respect struct tags\n\tExpected: %v\n\tOut: %v", expected, out)
 	}
 }
+
+func TestMarshalTagKeys(t *testing.T) {
+	stru := struct {
+		RedmapOnly string `redmap:"redmap"`
+		JSONOnly   string `json:"json,omitempty,string"`
+		Both       string `redmap:"both" json:"unused"`
+		Ignored    string `json:"-"`
+		Omitted    string `json:",omitempty"`
+	}{
+		RedmapOnly: "redmap",
+		JSONOnly:   "json",
+		Both:       "both",
+		Ignored:    "ignored",
+	}
+	expected := map[string]string{
+		"redmap": "redmap",
+		"json":   "json",
+		"both":   "both",
+	}
+	var enc redmap.Encoder
+	enc.SetTagKeys("redmap", "json")
+	out, err := enc.Marshal(stru)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Marshal's output doesn't respect struct tags\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestMarshalInlineSeparator(t *testing.T) {
+	type Inner = struct {
+		String string
+	}
+	stru := struct {
+		Colon    Inner            `redmap:"colon,inline,sep=:"`
+		Comma    Inner            `redmap:"comma,inline,sep=,"`
+		Empty    Inner            `redmap:"empty,inline,sep="`
+		NoPrefix Inner            `redmap:",inline,noprefix"`
+		Map      stubMapMarshaler `redmap:"map,inline,sep=_"`
+	}{
+		Colon:    Inner{String: "colon"},
+		Comma:    Inner{String: "comma"},
+		Empty:    Inner{String: "empty"},
+		NoPrefix: Inner{String: "noprefix"},
+	}
+	expected := map[string]string{
+		"colon:String": "colon",
+		"comma,String": "comma",
+		"emptyString":  "empty",
+		"String":       "noprefix",
+	}
+	for k, v := range mapMarshalerOut {
+		expected["map_"+k] = v
+	}
+	out, err := redmap.Marshal(stru)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Marshal's output doesn't respect struct tags\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestMarshalNullField(t *testing.T) {
+	type Inner = struct {
+		String string
+	}
+	zero := 0
+	stru := struct {
+		Nil       *int
+		Zero      *int
+		FieldOmit *int   `redmap:",omitempty"`
+		Inline    *Inner `redmap:",inline"`
+	}{Zero: &zero}
+	expected := map[string]string{"Nil": "null", "Zero": "0", "Inline": "null"}
+	var enc redmap.Encoder
+	enc.SetNull("null")
+	out, err := enc.Marshal(stru)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestMarshalNullAmbiguous(t *testing.T) {
+	empty := ""
+	stru := struct{ Ptr *string }{Ptr: &empty}
+	var enc redmap.Encoder
+	enc.SetNull("")
+	_, err := enc.Marshal(stru)
+	if !errors.Is(err, redmap.ErrAmbiguousNull) {
+		t.Fatalf("Marshal returned %q but %q was expected", err, redmap.ErrAmbiguousNull)
+	}
+	stru.Ptr = nil
+	out, err := enc.Marshal(stru)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if expected := map[string]string{"Ptr": ""}; !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestMarshalOmitZero(t *testing.T) {
+	stru := struct {
+		Zero    int `redmap:",omitzero"`
+		NonZero int `redmap:",omitzero"`
+	}{NonZero: 1}
+	expected := map[string]string{"NonZero": "1"}
+	out, err := redmap.Marshal(stru)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Marshal's output doesn't respect struct tags\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestMarshalPointerReceiver(t *testing.T) {
+	type stru = struct {
+		Stringer      stubPtrStringer
+		TextMarshaler stubPtrTextMarshaler
+		PtrStringer   *stubPtrStringer
+		Map           stubPtrMapMarshaler `redmap:",inline"`
+	}
+	expected := map[string]string{
+		"Stringer":      stringerOut,
+		"TextMarshaler": textMarshalerOut,
+		"PtrStringer":   stringerOut,
+	}
+	for k, v := range mapMarshalerOut {
+		expected["Map."+k] = v
+	}
+	// Marshal both a non-addressable and an addressable struct.
+	tests := []interface{}{stru{PtrStringer: &stubPtrStringer{}}, &stru{PtrStringer: &stubPtrStringer{}}}
+	for _, test := range tests {
+		out, err := redmap.Marshal(test)
+		if err != nil {
+			t.Fatalf("Marshal returned unexpected error %q", err)
+		}
+		if !reflect.DeepEqual(out, expected) {
+			t.Fatalf("Marshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", test, expected, out)
+		}
+	}
+	out, err := redmap.Marshal(stubPtrMapMarshaler{})
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, mapMarshalerOut) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", mapMarshalerOut, out)
+	}
+}
+
+func TestMarshalBoolFormat(t *testing.T) {
+	stru := struct {
+		True  bool
+		False bool
+	}{True: true}
+	expected := map[string]string{"True": "1", "False": "0"}
+	var enc redmap.Encoder
+	enc.SetBoolFormat("1", "0")
+	out, err := enc.Marshal(stru)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+// stubNode is a self-referential type.
+type stubNode struct {
+	Value int
+	Next  *stubNode `redmap:",inline"`
+}
+
+func TestMarshalNilInline(t *testing.T) {
+	in := &stubNode{Value: 1, Next: &stubNode{Value: 2}}
+	expected := map[string]string{"Value": "1", "Next.Value": "2"}
+	out, err := redmap.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+	var back stubNode
+	err = redmap.Unmarshal(out, &back)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(&back, in) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, &back)
+	}
+}
+
+func TestMarshalCycle(t *testing.T) {
+	first := &stubNode{Value: 1}
+	first.Next = &stubNode{Value: 2, Next: first}
+	_, err := redmap.Marshal(first)
+	if !errors.Is(err, redmap.ErrCycle) {
+		t.Fatalf("Marshal returned %q but %q was expected", err, redmap.ErrCycle)
+	}
+}
+
+func TestMarshalMaxDepth(t *testing.T) {
+	list := &stubNode{Value: 1, Next: &stubNode{Value: 2, Next: &stubNode{Value: 3}}}
+	var enc redmap.Encoder
+	enc.SetMaxDepth(2)
+	_, err := enc.Marshal(list)
+	if !errors.Is(err, redmap.ErrMaxDepth) {
+		t.Fatalf("Marshal returned %q but %q was expected", err, redmap.ErrMaxDepth)
+	}
+	enc.SetMaxDepth(3)
+	out, err := enc.Marshal(list)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	expected := map[string]string{"Value": "1", "Next.Value": "2", "Next.Next.Value": "3"}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
@@ -0,0 +1,121 @@
+package redmap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// numberFormat describes how numbers are converted to strings.
+type numberFormat struct {
+	base      int
+	prefix    bool
+	floatFmt  byte
+	floatPrec int
+}
+
+var defaultNumberFormat = numberFormat{base: 10, floatFmt: 'f', floatPrec: -1}
+
+// basePrefixes maps the bases that can be prefixed to their prefix.
+var basePrefixes = map[int]string{2: "0b", 8: "0o", 16: "0x"}
+
+// baseNames maps the names accepted by the `base` option to a base and whether it's prefixed.
+var baseNames = map[string]struct {
+	base   int
+	prefix bool
+}{
+	"bin": {2, false}, "oct": {8, false}, "hex": {16, false},
+	"0b": {2, true}, "0o": {8, true}, "0x": {16, true},
+}
+
+// SetIntBase sets the base, between 2 and 36, in which integers are written and parsed.
+// If prefix is true, integers are written with the "0b", "0o" or "0x" prefix, in which case
+// base must be 2, 8 or 16, and parsed accepting any of such prefixes. By default, integers are
+// in base 10 without prefix. The `base` option of a field's format string takes precedence.
+func (c *config) SetIntBase(base int, prefix bool) {
+	c.intBase, c.intPrefix = base, prefix
+}
+
+// SetFloatFormat sets the format and precision of floating-point and complex numbers,
+// with the same meaning as strconv.FormatFloat. Format 'b' is not supported, as it cannot
+// be parsed back. By default, format 'f' is used with the smallest precision necessary
+// to represent the value exactly. Unmarshal parses any format regardless of this setting.
+// The `fmt` and `prec` options of a field's format string take precedence.
+func (c *config) SetFloatFormat(format byte, prec int) {
+	c.floatFmt, c.floatPrec, c.hasFloatPrec = format, prec, true
+}
+
+// numberFormat returns the number format requested by tags, falling back to the settings of c.
+func (c *config) numberFormat(tags structTags) (numberFormat, error) {
+	f := defaultNumberFormat
+	if c.intBase != 0 {
+		f.base, f.prefix = c.intBase, c.intPrefix
+	}
+	if c.floatFmt != 0 {
+		f.floatFmt = c.floatFmt
+	}
+	if c.hasFloatPrec {
+		f.floatPrec = c.floatPrec
+	}
+	if tags.base != "" {
+		if named, ok := baseNames[tags.base]; ok {
+			f.base, f.prefix = named.base, named.prefix
+		} else {
+			base, err := strconv.Atoi(tags.base)
+			if err != nil {
+				return numberFormat{}, fmt.Errorf("invalid base %q", tags.base)
+			}
+			f.base, f.prefix = base, false
+		}
+	}
+	if tags.floatFmt != "" {
+		if len(tags.floatFmt) != 1 {
+			return numberFormat{}, fmt.Errorf("invalid float format %q", tags.floatFmt)
+		}
+		f.floatFmt = tags.floatFmt[0]
+	}
+	if tags.floatPrec != "" {
+		prec, err := strconv.Atoi(tags.floatPrec)
+		if err != nil {
+			return numberFormat{}, fmt.Errorf("invalid float precision %q", tags.floatPrec)
+		}
+		f.floatPrec = prec
+	}
+	return f, f.validate()
+}
+
+func (f numberFormat) validate() error {
+	if f.base < 2 || f.base > 36 {
+		return fmt.Errorf("invalid base %d", f.base)
+	}
+	if _, ok := basePrefixes[f.base]; f.prefix && !ok {
+		return fmt.Errorf("base %d cannot have a prefix", f.base)
+	}
+	if !strings.ContainsRune("eEfgGxX", rune(f.floatFmt)) {
+		return fmt.Errorf("invalid float format %q", f.floatFmt)
+	}
+	return nil
+}
+
+func (f numberFormat) formatInt(i int64) string {
+	if i < 0 {
+		return "-" + f.formatUint(uint64(-i))
+	}
+	return f.formatUint(uint64(i))
+}
+
+func (f numberFormat) formatUint(u uint64) string {
+	str := strconv.FormatUint(u, f.base)
+	if f.prefix {
+		str = basePrefixes[f.base] + str
+	}
+	return str
+}
+
+// parseBase returns the base argument for strconv's parsing functions.
+func (f numberFormat) parseBase() int {
+	if f.prefix {
+		return 0 // Base is deduced from the prefix.
+	}
+	return f.base
+}
@@ -0,0 +1,116 @@
+package redmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+func TestNumberFormatTags(t *testing.T) {
+	type stru struct {
+		Hex       int     `redmap:"hex,base=hex"`
+		HexPrefix int     `redmap:"hexPrefix,base=0x"`
+		Oct       uint8   `redmap:"oct,base=0o"`
+		Bin       int16   `redmap:"bin,base=bin"`
+		Base36    uint64  `redmap:"base36,base=36"`
+		Exp       float64 `redmap:"exp,fmt=e,prec=3"`
+		General   float32 `redmap:"general,fmt=g"`
+		Fixed     float64 `redmap:"fixed,prec=2"`
+	}
+	in := stru{
+		Hex:       255,
+		HexPrefix: -255,
+		Oct:       8,
+		Bin:       5,
+		Base36:    35,
+		Exp:       123456,
+		General:   1e21,
+		Fixed:     3.14,
+	}
+	expected := map[string]string{
+		"hex":       "ff",
+		"hexPrefix": "-0xff",
+		"oct":       "0o10",
+		"bin":       "101",
+		"base36":    "z",
+		"exp":       "1.235e+05",
+		"general":   "1e+21",
+		"fixed":     "3.14",
+	}
+	mp, err := redmap.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, mp)
+	}
+	var out stru
+	err = redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	in.Exp = 123500 // Precision is lost.
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, out)
+	}
+}
+
+func TestNumberFormatSettings(t *testing.T) {
+	type stru struct {
+		ID    uint64
+		Dec   int `redmap:",base=10"`
+		Float float64
+	}
+	in := stru{ID: 0xdeadbeef, Dec: 10, Float: 0.000001}
+	expected := map[string]string{"ID": "0xdeadbeef", "Dec": "10", "Float": "1e-06"}
+	var enc redmap.Encoder
+	enc.SetIntBase(16, true)
+	enc.SetFloatFormat('g', -1)
+	mp, err := enc.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, mp)
+	}
+	var (
+		dec redmap.Decoder
+		out stru
+	)
+	dec.SetIntBase(16, true)
+	err = dec.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, out)
+	}
+}
+
+func TestNumberFormatInvalid(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			V int `redmap:",base=1"`
+		}{},
+		&struct {
+			V int `redmap:",base=nope"`
+		}{},
+		&struct {
+			V float64 `redmap:",fmt=b"`
+		}{},
+		&struct {
+			V float64 `redmap:",prec=nope"`
+		}{},
+	}
+	for _, test := range tests {
+		if _, err := redmap.Marshal(test); err == nil {
+			t.Fatalf("Marshal of %T did not return error", test)
+		}
+	}
+	var enc redmap.Encoder
+	enc.SetIntBase(10, true)
+	if _, err := enc.Marshal(struct{ V int }{}); err == nil {
+		t.Fatal("Marshal with prefixed base 10 did not return error")
+	}
+}
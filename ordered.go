@@ -0,0 +1,101 @@
+package redmap
+
+// KeyValue is a key-value pair produced by MarshalOrdered.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// MarshalOrdered works like Marshal, but it returns the key-value pairs in the order of
+// the struct fields they come from, which is useful to produce human-readable output.
+// Keys coming from a StringMapMarshaler are sorted, since maps have no order.
+//
+// MarshalOrdered is equivalent to calling MarshalOrdered on a zero Encoder.
+func MarshalOrdered(v interface{}) ([]KeyValue, error) {
+	var enc Encoder
+	return enc.MarshalOrdered(v)
+}
+
+// MarshalOrdered works like the MarshalOrdered function, but with the settings of e.
+func (e *Encoder) MarshalOrdered(v interface{}) ([]KeyValue, error) {
+	val, err := validValue(v)
+	if err != nil {
+		return nil, err
+	}
+	var ret orderedSink
+	if err := e.marshal(&ret, "", val); err != nil {
+		return nil, err
+	}
+	pairs := make([]KeyValue, len(ret.pairs))
+	for i, pair := range ret.pairs {
+		pairs[i] = KeyValue{Key: pair.Key, Value: pair.Value.(string)}
+	}
+	return pairs, nil
+}
+
+// NativeKeyValue is a key-value pair produced by MarshalNative.
+type NativeKeyValue struct {
+	Key string
+	// Value is either a bool, an int64, a uint64, a float64 or a string.
+	Value interface{}
+}
+
+// MarshalNative works like MarshalOrdered, but fields having a boolean, integer or floating-point
+// kind keep their value as a bool, an int64, a uint64 or a float64, rather than being converted
+// to strings. This is not the case if the field has a custom encoding, that is a codec,
+// an implementation of encoding.TextMarshaler or fmt.Stringer, or options of the format
+// string such as `base`, `fmt` and `json`. All other fields are converted to strings.
+// A float32 becomes the float64 closest to its shortest decimal representation, so 0.1 stays 0.1.
+//
+// MarshalNative is equivalent to calling MarshalNative on a zero Encoder.
+func MarshalNative(v interface{}) ([]NativeKeyValue, error) {
+	var enc Encoder
+	return enc.MarshalNative(v)
+}
+
+// MarshalNative works like the MarshalNative function, but with the settings of e.
+func (e *Encoder) MarshalNative(v interface{}) ([]NativeKeyValue, error) {
+	val, err := validValue(v)
+	if err != nil {
+		return nil, err
+	}
+	var ret nativeOrderedSink
+	if err := e.marshal(&ret, "", val); err != nil {
+		return nil, err
+	}
+	return ret.pairs, nil
+}
+
+// orderedSink is the sink of MarshalOrdered.
+type orderedSink struct {
+	pairs []NativeKeyValue
+	// index maps each key to its position in pairs.
+	index map[string]int
+}
+
+func (s *orderedSink) set(key, value string) {
+	s.setValue(key, value)
+}
+
+func (s *orderedSink) orderedKeys() {}
+
+func (s *orderedSink) setValue(key string, value interface{}) {
+	if i, ok := s.index[key]; ok {
+		s.pairs[i].Value = value
+		return
+	}
+	if s.index == nil {
+		s.index = make(map[string]int)
+	}
+	s.index[key] = len(s.pairs)
+	s.pairs = append(s.pairs, NativeKeyValue{Key: key, Value: value})
+}
+
+// nativeOrderedSink is the sink of MarshalNative.
+type nativeOrderedSink struct {
+	orderedSink
+}
+
+func (s *nativeOrderedSink) setNative(key string, value interface{}) {
+	s.setValue(key, value)
+}
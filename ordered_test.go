@@ -0,0 +1,76 @@
+package redmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+func TestMarshalOrdered(t *testing.T) {
+	stru := struct {
+		Zeta  string
+		Alpha string
+		Inner struct {
+			Beta  int
+			Gamma int
+		} `redmap:",inline"`
+		Map   stubMapMarshaler `redmap:",inline"`
+		Omega bool
+	}{Zeta: "z", Alpha: "a", Omega: true}
+	stru.Inner.Beta = 1
+	stru.Inner.Gamma = 2
+	expected := []redmap.KeyValue{
+		{Key: "Zeta", Value: "z"},
+		{Key: "Alpha", Value: "a"},
+		{Key: "Inner.Beta", Value: "1"},
+		{Key: "Inner.Gamma", Value: "2"},
+		{Key: "Map.field1", Value: "value1"},
+		{Key: "Map.field2", Value: "value2"},
+		{Key: "Omega", Value: "true"},
+	}
+	out, err := redmap.MarshalOrdered(stru)
+	if err != nil {
+		t.Fatalf("MarshalOrdered returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("MarshalOrdered's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestMarshalNative(t *testing.T) {
+	stru := struct {
+		Str    string
+		Bool   bool
+		Int    int8
+		Uint   uint
+		Float  float32
+		Nil    *int
+		Hex    int `redmap:",base=hex"`
+		Text   stubTextMarshaler
+		Inner  struct{ Depth int } `redmap:",inline"`
+		Slice  []int               `redmap:",list"`
+		NilPtr *int                `redmap:",omitempty"`
+	}{Str: "s", Bool: true, Int: -1, Uint: 2, Float: 0.5, Hex: 255}
+	stru.Inner.Depth = 3
+	stru.Slice = []int{1, 2}
+	expected := []redmap.NativeKeyValue{
+		{Key: "Str", Value: "s"},
+		{Key: "Bool", Value: true},
+		{Key: "Int", Value: int64(-1)},
+		{Key: "Uint", Value: uint64(2)},
+		{Key: "Float", Value: float64(0.5)},
+		{Key: "Nil", Value: int64(0)},
+		{Key: "Hex", Value: "ff"},
+		{Key: "Text", Value: textMarshalerOut},
+		{Key: "Inner.Depth", Value: int64(3)},
+		{Key: "Slice", Value: "1,2"},
+	}
+	out, err := redmap.MarshalNative(stru)
+	if err != nil {
+		t.Fatalf("MarshalNative returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("MarshalNative's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
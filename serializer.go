@@ -0,0 +1,34 @@
+package redmap
+
+import "encoding/json"
+
+// Serializer converts whole values, such as slices of structs or nested maps, to and from bytes.
+// It is used for fields with the `json` option in their format string, so that values which
+// can't be flattened into keys are stored under a single key.
+// Unmarshal receives a pointer to the field, even if the field is a pointer itself,
+// so that, for example, "null" sets a pointer field to nil with encoding/json.
+type Serializer interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// jsonSerializer is the default Serializer, based on encoding/json.
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonSerializer) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// SetSerializer sets the Serializer used for fields with the `json` option,
+// for example to store them as msgpack or gob instead. By default, encoding/json is used.
+func (c *config) SetSerializer(s Serializer) {
+	c.serializer = s
+}
+
+// serializerOrDefault returns the Serializer set with SetSerializer, or the default one.
+func (c *config) serializerOrDefault() Serializer {
+	if c.serializer == nil {
+		return jsonSerializer{}
+	}
+	return c.serializer
+}
@@ -0,0 +1,122 @@
+package redmap_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/gob"
+	"reflect"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+// gobSerializer implements redmap.Serializer with encoding/gob, encoded as base64.
+type gobSerializer struct{}
+
+func (gobSerializer) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+func (gobSerializer) Unmarshal(data []byte, v interface{}) error {
+	raw, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return err
+	}
+	return gob.NewDecoder(bytes.NewReader(raw)).Decode(v)
+}
+
+type serializedStruct struct {
+	Items   []serializedItem      `redmap:"items,json"`
+	Nested  map[string][]int      `redmap:"nested,json"`
+	Pointer *map[string]string    `redmap:"pointer,json"`
+	Plain   string                `redmap:"plain"`
+	Ignored map[string]complex128 `redmap:"-"`
+}
+
+type serializedItem struct {
+	Name  string
+	Count int
+}
+
+func TestSerializerJSON(t *testing.T) {
+	in := serializedStruct{
+		Items:   []serializedItem{{Name: "a", Count: 1}, {Name: "b", Count: 2}},
+		Nested:  map[string][]int{"x": {1, 2}},
+		Pointer: &map[string]string{"k": "v"},
+		Plain:   "plain",
+	}
+	expected := map[string]string{
+		"items":   `[{"Name":"a","Count":1},{"Name":"b","Count":2}]`,
+		"nested":  `{"x":[1,2]}`,
+		"pointer": `{"k":"v"}`,
+		"plain":   "plain",
+	}
+	mp, err := redmap.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, mp)
+	}
+	var out serializedStruct
+	err = redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, out)
+	}
+}
+
+func TestSerializerCustom(t *testing.T) {
+	in := serializedStruct{
+		Items:   []serializedItem{{Name: "a", Count: 1}},
+		Nested:  map[string][]int{"x": {1, 2}},
+		Pointer: &map[string]string{"k": "v"},
+	}
+	var enc redmap.Encoder
+	enc.SetSerializer(gobSerializer{})
+	mp, err := enc.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	var (
+		dec redmap.Decoder
+		out serializedStruct
+	)
+	dec.SetSerializer(gobSerializer{})
+	err = dec.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", in, out)
+	}
+}
+
+func TestSerializerNull(t *testing.T) {
+	in := struct {
+		P *[]int `redmap:"p,json"`
+	}{}
+	mp, err := redmap.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	expected := map[string]string{"p": "null"}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, mp)
+	}
+	out := in
+	out.P = &[]int{1}
+	err = redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if out.P != nil {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: nil\n\tOut: %v", *out.P)
+	}
+}
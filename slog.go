@@ -0,0 +1,101 @@
+//go:build go1.21
+
+package redmap
+
+import "log/slog"
+
+// LogValuer returns a slog.LogValuer whose LogValue method marshals v into a group
+// having one string attribute per key returned by Marshal, in the order of the struct fields.
+// Unlike Marshal, inlined structs become nested groups named after the field, instead of
+// having their keys prefixed. Fields inlined with the `noprefix` option are kept in the
+// enclosing group.
+//
+// v is marshaled lazily, each time the log record is handled. If marshaling fails,
+// LogValue returns the error as the value.
+//
+// LogValuer requires Go 1.21 or later, as log/slog does. It is equivalent to calling
+// LogValuer on a zero Encoder.
+func LogValuer(v interface{}) slog.LogValuer {
+	var enc Encoder
+	return enc.LogValuer(v)
+}
+
+// LogValuer works like the LogValuer function, but with the settings of e.
+// The settings are copied, so that changing e afterwards has no effect.
+func (e *Encoder) LogValuer(v interface{}) slog.LogValuer {
+	return logValuer{enc: *e, v: v}
+}
+
+// logValuer is the slog.LogValuer returned by LogValuer.
+type logValuer struct {
+	enc Encoder
+	v   interface{}
+}
+
+func (l logValuer) LogValue() slog.Value {
+	val, err := validValue(l.v)
+	if err != nil {
+		return slog.AnyValue(err)
+	}
+	var ret slogSink
+	if err := l.enc.marshal(&ret, "", val); err != nil {
+		return slog.AnyValue(err)
+	}
+	return ret.value()
+}
+
+// slogSink is the sink of LogValuer.
+type slogSink struct {
+	keys   []string
+	values map[string]string
+	groups map[string]*slogSink
+}
+
+func (s *slogSink) set(key, value string) {
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	if !s.has(key) {
+		s.keys = append(s.keys, key)
+	}
+	s.values[key] = value
+}
+
+func (s *slogSink) orderedKeys() {}
+
+func (s *slogSink) group(name string) sink {
+	if g, ok := s.groups[name]; ok {
+		return g
+	}
+	if s.groups == nil {
+		s.groups = make(map[string]*slogSink)
+	}
+	if !s.has(name) {
+		s.keys = append(s.keys, name)
+	}
+	g := new(slogSink)
+	s.groups[name] = g
+	return g
+}
+
+// value returns the group value holding the attributes of s.
+// A key used both as a value and as a group, which happens when a nil inlined
+// pointer is marshaled as null, is written as a value.
+func (s *slogSink) value() slog.Value {
+	attrs := make([]slog.Attr, 0, len(s.keys))
+	for _, key := range s.keys {
+		if value, ok := s.values[key]; ok {
+			attrs = append(attrs, slog.String(key, value))
+		} else {
+			attrs = append(attrs, slog.Attr{Key: key, Value: s.groups[key].value()})
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// has reports whether key is already used as a value or as a group.
+func (s *slogSink) has(key string) bool {
+	_, isValue := s.values[key]
+	_, isGroup := s.groups[key]
+	return isValue || isGroup
+}
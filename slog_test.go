@@ -0,0 +1,61 @@
+//go:build go1.21
+
+package redmap_test
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+func TestLogValuer(t *testing.T) {
+	stru := struct {
+		Name  string `redmap:"name"`
+		Inner struct {
+			Beta  int
+			Gamma int `redmap:"gamma"`
+		} `redmap:"inner,inline,sep=:"`
+		Flat struct {
+			Delta string
+		} `redmap:",inline,noprefix"`
+		Shape stubShape        `redmap:"shape,inline,typekey=kind"`
+		Map   stubMapMarshaler `redmap:",inline"`
+	}{Name: "n", Shape: stubCircle{Radius: 2}}
+	stru.Inner.Beta = 1
+	stru.Inner.Gamma = 2
+	stru.Flat.Delta = "d"
+	expected := slog.GroupValue(
+		slog.String("name", "n"),
+		slog.Group("inner", slog.String("Beta", "1"), slog.String("gamma", "2")),
+		slog.String("Delta", "d"),
+		slog.Group("shape", slog.String("Radius", "2"), slog.String("kind", "circle")),
+		slog.Group("Map", slog.String("field1", "value1"), slog.String("field2", "value2")),
+	)
+	out := redmap.LogValuer(&stru).LogValue()
+	if !out.Equal(expected) {
+		t.Fatalf("LogValue's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestLogValuerNull(t *testing.T) {
+	type inner struct{ Field int }
+	stru := struct {
+		Ptr *inner `redmap:"ptr,inline"`
+	}{}
+	var enc redmap.Encoder
+	enc.SetNull("nil")
+	expected := slog.GroupValue(slog.String("ptr", "nil"))
+	out := enc.LogValuer(stru).LogValue()
+	if !out.Equal(expected) {
+		t.Fatalf("LogValue's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestLogValuerError(t *testing.T) {
+	out := redmap.LogValuer(nil).LogValue()
+	if err, ok := out.Any().(error); !ok || !errors.Is(err, redmap.ErrNilValue) {
+		t.Fatalf("LogValue returned %v instead of %q", out, redmap.ErrNilValue)
+	}
+}
@@ -12,17 +12,58 @@ const (
 	tagIgnore    = "-"
 	tagInline    = "inline"
 	tagOmitEmpty = "omitempty"
+	tagOmitZero  = "omitzero"
+	tagNoPrefix  = "noprefix"
+	tagJSON      = "json"
+
+	tagOptionAssign = "="
+	tagSep          = "sep"
+	tagCodec        = "codec"
+	tagList         = "list"
+	tagBase         = "base"
+	tagFloatFmt     = "fmt"
+	tagFloatPrec    = "prec"
+	tagTypeKey      = "typekey"
+	tagUsage        = "usage"
 )
 
+var defaultTagKeys = []string{tagKeyword}
+
 type structTags struct {
 	name      string
 	ignored   bool
 	inline    bool
 	omitempty bool
+	omitzero  bool
+	noprefix  bool
+	json      bool
+	customSep bool
+	sep       string
+	codec     string
+	list      bool
+	listSep   string
+	base      string
+	floatFmt  string
+	floatPrec string
+	typekey   string
+	usage     string
 }
 
-func redmapTags(t reflect.StructTag) structTags {
-	str, has := t.Lookup(tagKeyword)
+// redmapTags parses the format string stored under the first of keys found in t.
+// If keys is empty, the default tagKeyword is used.
+func redmapTags(t reflect.StructTag, keys []string) structTags {
+	if len(keys) == 0 {
+		keys = defaultTagKeys
+	}
+	var (
+		str string
+		has bool
+	)
+	for _, key := range keys {
+		if str, has = t.Lookup(key); has {
+			break
+		}
+	}
 	if !has || str == "" {
 		return structTags{}
 	}
@@ -33,12 +74,46 @@ func redmapTags(t reflect.StructTag) structTags {
 
 	toks := strings.Split(str, tagSeparator)
 	tags := structTags{name: toks[0]}
-	for _, t := range toks[1:] {
-		switch t {
+	for i := 1; i < len(toks); i++ {
+		opt, val, hasVal := toks[i], "", false
+		if idx := strings.Index(opt, tagOptionAssign); idx >= 0 {
+			opt, val, hasVal = opt[:idx], opt[idx+len(tagOptionAssign):], true
+		}
+		if opt == tagUsage {
+			// The usage extends to the end of the format string, so that it can contain commas.
+			tags.usage = strings.Join(append([]string{val}, toks[i+1:]...), tagSeparator)
+			break
+		}
+		if hasVal && val == "" && i+1 < len(toks) && toks[i+1] == "" {
+			// The option's value is the separator itself, as in "sep=,".
+			val = tagSeparator
+			i++
+		}
+		switch opt {
 		case tagInline:
 			tags.inline = true
 		case tagOmitEmpty:
 			tags.omitempty = true
+		case tagOmitZero:
+			tags.omitzero = true
+		case tagNoPrefix:
+			tags.noprefix = true
+		case tagJSON:
+			tags.json = true
+		case tagSep:
+			tags.customSep, tags.sep = hasVal, val
+		case tagCodec:
+			tags.codec = val
+		case tagList:
+			tags.list, tags.listSep = true, val
+		case tagBase:
+			tags.base = val
+		case tagFloatFmt:
+			tags.floatFmt = val
+		case tagFloatPrec:
+			tags.floatPrec = val
+		case tagTypeKey:
+			tags.typekey = val
 		}
 	}
 	return tags
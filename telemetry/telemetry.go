@@ -0,0 +1,135 @@
+// Package telemetry turns structs into Prometheus label sets and OpenTelemetry-style
+// attributes, using the conversions of redmap.
+//
+// Since dots are not allowed in Prometheus label names, the fields of inlined structs
+// are separated by "_" by default.
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/livingsilver94/redmap"
+)
+
+// ErrInvalidName is returned when a key is not a valid label or attribute name.
+var ErrInvalidName = errors.New("not a valid name")
+
+// defaultSep is the default separator of inlined fields.
+const defaultSep = "_"
+
+// Label is a Prometheus label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Attribute is an OpenTelemetry-style attribute.
+type Attribute struct {
+	Key string
+	// Value is either a bool, an int64, a float64 or a string.
+	Value interface{}
+}
+
+// Encoder converts structs into labels and attributes.
+// The zero value is ready to use.
+type Encoder struct {
+	// Options marshals structs into the keys and values of labels and attributes.
+	// Its separator of inlined fields is "_" unless set with SetSeparator.
+	Options redmap.Encoder
+}
+
+// NewEncoder returns a new Encoder separating inlined fields by "_".
+func NewEncoder() *Encoder {
+	var e Encoder
+	e.Options.SetSeparator(defaultSep)
+	return &e
+}
+
+// Labels returns the label set representing v, sorted by name, with one label
+// per key returned by redmap.Marshal. Label names must match the regular expression
+// [a-zA-Z_][a-zA-Z0-9_]* and must not start with "__", which is reserved
+// for internal use, otherwise an error wrapping ErrInvalidName is returned.
+func (e *Encoder) Labels(v interface{}) ([]Label, error) {
+	pairs, err := e.options().MarshalOrdered(v)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]Label, len(pairs))
+	for i, pair := range pairs {
+		if !validLabelName(pair.Key) {
+			return nil, fmt.Errorf("label name %q is %w", pair.Key, ErrInvalidName)
+		}
+		ret[i] = Label{Name: pair.Key, Value: pair.Value}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret, nil
+}
+
+// Labels returns the label set representing v. See Encoder.Labels for details.
+func Labels(v interface{}) ([]Label, error) {
+	return NewEncoder().Labels(v)
+}
+
+// Attributes returns the attributes representing v, in the order of the struct fields
+// they come from, as returned by redmap.Encoder.MarshalNative. Booleans, integers and
+// floating-point numbers keep their type, and unsigned integers are converted to int64
+// unless they overflow, in which case they are written as strings. Attribute keys
+// must not be empty, otherwise an error wrapping ErrInvalidName is returned.
+func (e *Encoder) Attributes(v interface{}) ([]Attribute, error) {
+	pairs, err := e.options().MarshalNative(v)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]Attribute, len(pairs))
+	for i, pair := range pairs {
+		if pair.Key == "" {
+			return nil, fmt.Errorf("attribute key %q is %w", pair.Key, ErrInvalidName)
+		}
+		value := pair.Value
+		if u, ok := value.(uint64); ok {
+			if u <= math.MaxInt64 {
+				value = int64(u)
+			} else {
+				value = strconv.FormatUint(u, 10)
+			}
+		}
+		ret[i] = Attribute{Key: pair.Key, Value: value}
+	}
+	return ret, nil
+}
+
+// Attributes returns the attributes representing v. See Encoder.Attributes for details.
+func Attributes(v interface{}) ([]Attribute, error) {
+	return NewEncoder().Attributes(v)
+}
+
+// options returns a copy of e.Options separating inlined fields by "_",
+// unless another separator is set.
+func (e *Encoder) options() *redmap.Encoder {
+	opts := e.Options
+	if _, ok := opts.Separator(); !ok {
+		opts.SetSeparator(defaultSep)
+	}
+	return &opts
+}
+
+// validLabelName reports whether name is a valid Prometheus label name.
+func validLabelName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
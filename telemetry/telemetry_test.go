@@ -0,0 +1,121 @@
+package telemetry_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/livingsilver94/redmap/telemetry"
+)
+
+type request struct {
+	Route  string  `redmap:"route"`
+	Status int     `redmap:"status"`
+	Cached bool    `redmap:"cached"`
+	Ratio  float64 `redmap:"ratio"`
+	Size   uint64  `redmap:"size"`
+	Client struct {
+		Region string `redmap:"region"`
+	} `redmap:"client,inline"`
+}
+
+func newRequest() request {
+	req := request{Route: "/users", Status: 200, Cached: true, Ratio: 0.25, Size: 1 << 63}
+	req.Client.Region = "eu"
+	return req
+}
+
+func TestLabels(t *testing.T) {
+	expected := []telemetry.Label{
+		{Name: "cached", Value: "true"},
+		{Name: "client_region", Value: "eu"},
+		{Name: "ratio", Value: "0.25"},
+		{Name: "route", Value: "/users"},
+		{Name: "size", Value: "9223372036854775808"},
+		{Name: "status", Value: "200"},
+	}
+	out, err := telemetry.Labels(newRequest())
+	if err != nil {
+		t.Fatalf("Labels returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Labels's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestLabelsInvalid(t *testing.T) {
+	tests := []interface{}{
+		struct {
+			V int `redmap:"a-b"`
+		}{},
+		struct {
+			V int `redmap:"1a"`
+		}{},
+		struct {
+			V int `redmap:"__reserved"`
+		}{},
+		struct {
+			V struct{ W int } `redmap:"v,inline,sep=."`
+		}{},
+	}
+	for _, test := range tests {
+		if _, err := telemetry.Labels(test); !errors.Is(err, telemetry.ErrInvalidName) {
+			t.Fatalf("Labels of %#v returned %v instead of %q", test, err, telemetry.ErrInvalidName)
+		}
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	expected := []telemetry.Attribute{
+		{Key: "route", Value: "/users"},
+		{Key: "status", Value: int64(200)},
+		{Key: "cached", Value: true},
+		{Key: "ratio", Value: 0.25},
+		{Key: "size", Value: "9223372036854775808"},
+		{Key: "client.region", Value: "eu"},
+	}
+	enc := telemetry.NewEncoder()
+	enc.Options.SetSeparator(".")
+	out, err := enc.Attributes(newRequest())
+	if err != nil {
+		t.Fatalf("Attributes returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Attributes's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestEncoderZero(t *testing.T) {
+	in := struct {
+		Ratio  float32 `redmap:"ratio"`
+		Client struct {
+			Region string `redmap:"region"`
+		} `redmap:"client,inline"`
+	}{Ratio: 0.1}
+	in.Client.Region = "eu"
+	var enc telemetry.Encoder
+
+	expectedLabels := []telemetry.Label{
+		{Name: "client_region", Value: "eu"},
+		{Name: "ratio", Value: "0.1"},
+	}
+	labels, err := enc.Labels(in)
+	if err != nil {
+		t.Fatalf("Labels returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Fatalf("Labels's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expectedLabels, labels)
+	}
+
+	expectedAttrs := []telemetry.Attribute{
+		{Key: "ratio", Value: 0.1},
+		{Key: "client_region", Value: "eu"},
+	}
+	attrs, err := enc.Attributes(in)
+	if err != nil {
+		t.Fatalf("Attributes returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(attrs, expectedAttrs) {
+		t.Fatalf("Attributes's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expectedAttrs, attrs)
+	}
+}
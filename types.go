@@ -0,0 +1,122 @@
+package redmap
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// registeredTypes holds the types registered with RegisterType, by name and by type.
+var registeredTypes = struct {
+	sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{
+	byName: make(map[string]reflect.Type),
+	byType: make(map[reflect.Type]string),
+}
+
+// RegisterType registers type T under name, so that it can be stored in fields of interface type
+// having the `typekey` option in their format string. Marshal writes name under the type key
+// alongside the inlined fields of the concrete value, and Unmarshal uses it to allocate a value
+// of type T. T is commonly a pointer to struct, when its methods have a pointer receiver.
+// Registering a type under an already registered name, or a type already registered, replaces it.
+//
+// RegisterType is safe for concurrent use, but it is best called during initialization.
+func RegisterType[T any](name string) {
+	typ := reflect.TypeOf(new(T)).Elem()
+	registeredTypes.Lock()
+	defer registeredTypes.Unlock()
+	if old, ok := registeredTypes.byName[name]; ok {
+		delete(registeredTypes.byType, old)
+	}
+	if old, ok := registeredTypes.byType[typ]; ok {
+		delete(registeredTypes.byName, old)
+	}
+	registeredTypes.byName[name] = typ
+	registeredTypes.byType[typ] = name
+}
+
+// typeByName returns the type registered under name.
+func typeByName(name string) (reflect.Type, error) {
+	registeredTypes.RLock()
+	defer registeredTypes.RUnlock()
+	typ, ok := registeredTypes.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("no type is registered as %q", name)
+	}
+	return typ, nil
+}
+
+// nameByType returns the name typ is registered under.
+func nameByType(typ reflect.Type) (string, error) {
+	registeredTypes.RLock()
+	defer registeredTypes.RUnlock()
+	name, ok := registeredTypes.byType[typ]
+	if !ok {
+		return "", fmt.Errorf("type %s is not registered", typ)
+	}
+	return name, nil
+}
+
+// marshalTyped marshals the concrete value held by iface as an inlined struct,
+// adding its registered name under the type key requested by tags.
+func (e *encodeState) marshalTyped(prefix string, iface reflect.Value, tags structTags) error {
+	if iface.Kind() != reflect.Interface {
+		return fmt.Errorf("%s is not an interface and cannot have a type key", iface.Type())
+	}
+	if iface.IsNil() {
+		if e.hasNull {
+			e.out.set(prefix+tags.name, e.null)
+		}
+		return nil
+	}
+	concrete := iface.Elem()
+	name, err := nameByType(concrete.Type())
+	if err != nil {
+		return err
+	}
+	if err := e.marshalInline(prefix, concrete, tags); err != nil {
+		return err
+	}
+	return e.inline(prefix, tags, func(prefix string) error {
+		e.out.set(prefix+tags.typekey, name)
+		return nil
+	})
+}
+
+// unmarshalTyped allocates a value of the type named under the type key requested by tags,
+// unmarshals it as an inlined struct and stores it into iface.
+func (d *decodeState) unmarshalTyped(prefix string, iface reflect.Value, tags structTags) error {
+	if iface.Kind() != reflect.Interface {
+		return fmt.Errorf("%s is not an interface and cannot have a type key", iface.Type())
+	}
+	inner := d.inlinePrefix(prefix, tags)
+	name, ok, err := d.lookup(inner + tags.typekey)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if d.resetMissing {
+			return setZero(iface)
+		}
+		return nil
+	}
+	typ, err := typeByName(name)
+	if err != nil {
+		return err
+	}
+	if !typ.AssignableTo(iface.Type()) {
+		return fmt.Errorf("type %s, registered as %q, does not implement %s", typ, name, iface.Type())
+	}
+	concrete := reflect.New(typ).Elem()
+	target, err := allocPointers(concrete)
+	if err != nil {
+		return err
+	}
+	if err := d.unmarshalRecursive(inner, target); err != nil {
+		return err
+	}
+	iface.Set(concrete)
+	return nil
+}
@@ -0,0 +1,86 @@
+package redmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+type stubShape interface{ Area() float64 }
+
+type stubCircle struct{ Radius float64 }
+
+func (c stubCircle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+type stubRect struct{ Width, Height float64 }
+
+func (r *stubRect) Area() float64 { return r.Width * r.Height }
+
+type stubDrawing struct {
+	Name  string
+	Shape stubShape `redmap:"shape,inline,typekey=kind"`
+}
+
+func init() {
+	redmap.RegisterType[stubCircle]("circle")
+	redmap.RegisterType[*stubRect]("rect")
+	// A registered type that doesn't implement stubShape.
+	redmap.RegisterType[int]("notashape")
+}
+
+func TestTypeKeyRoundTrip(t *testing.T) {
+	tests := []struct {
+		In  stubDrawing
+		Out map[string]string
+	}{
+		{
+			In:  stubDrawing{Name: "c", Shape: stubCircle{Radius: 2}},
+			Out: map[string]string{"Name": "c", "shape.kind": "circle", "shape.Radius": "2"},
+		},
+		{
+			In:  stubDrawing{Name: "r", Shape: &stubRect{Width: 2, Height: 3}},
+			Out: map[string]string{"Name": "r", "shape.kind": "rect", "shape.Width": "2", "shape.Height": "3"},
+		},
+		{
+			In:  stubDrawing{Name: "nil"},
+			Out: map[string]string{"Name": "nil"},
+		},
+	}
+	for _, test := range tests {
+		mp, err := redmap.Marshal(test.In)
+		if err != nil {
+			t.Fatalf("Marshal returned unexpected error %q", err)
+		}
+		if !reflect.DeepEqual(mp, test.Out) {
+			t.Fatalf("Marshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", test.Out, mp)
+		}
+		var out stubDrawing
+		err = redmap.Unmarshal(mp, &out)
+		if err != nil {
+			t.Fatalf("Unmarshal returned unexpected error %q", err)
+		}
+		if !reflect.DeepEqual(out, test.In) {
+			t.Fatalf("Unmarshal's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", test.In, out)
+		}
+	}
+}
+
+func TestTypeKeyInvalid(t *testing.T) {
+	type unregistered struct{ stubCircle }
+	if _, err := redmap.Marshal(stubDrawing{Shape: unregistered{}}); err == nil {
+		t.Fatal("Marshal of an unregistered type did not return error")
+	}
+	tests := []map[string]string{
+		// Unknown type name.
+		{"shape.kind": "triangle"},
+		// Registered type that doesn't implement the interface.
+		{"shape.kind": "notashape"},
+	}
+	for _, test := range tests {
+		var out stubDrawing
+		if err := redmap.Unmarshal(test, &out); err == nil {
+			t.Fatalf("Unmarshal of %v did not return error", test)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // StringMapUnmarshaler is the interface implemented by types that can unmarshal themselves
@@ -19,9 +21,63 @@ type StringMapUnmarshaler interface {
 //
 // Unmarshal uses the inverse of the encodings that Marshal uses, so all the types supported
 // by it are also supported in Unmarshal, except fmt.Stringer which doesn't have an inverse.
+// To decode such types, register a parser with RegisterParser. Codecs registered with
+// RegisterCodec or Decoder.UseCodec take precedence over all of the built-in conversions.
+// Integers are parsed in the base set with Decoder.SetIntBase or the field's format string,
+// while floating-point numbers are parsed in any format accepted by strconv.ParseFloat.
+// Pointer fields are allocated as needed, unless a null representation is set with Decoder.SetNull
+// and the field's value equals it, in which case the pointer is set to nil.
+//
+// Fields whose key is missing from data are left untouched, unless Decoder.ResetMissing is used.
+// A value found in data is always stored, even if it is the zero value of the field's type.
 //
 // The decoding of each struct field can be customized by the format string documented in Marshal.
+// A nil pointer to an inlined struct is allocated only if data contains at least one key with
+// the inlined struct's prefix. Neither `omitempty` nor `omitzero` have any effect on unmarshaling.
+//
+// Unmarshal is equivalent to calling Unmarshal on a zero Decoder.
 func Unmarshal(data map[string]string, v interface{}) error {
+	var dec Decoder
+	return dec.Unmarshal(data, v)
+}
+
+// Decoder unmarshals maps of strings into Go values. The zero value is ready to use
+// and behaves like the Unmarshal function. Its methods customize the decoding and
+// must not be called concurrently with Unmarshal.
+type Decoder struct {
+	config
+	foldCase     bool
+	resetMissing bool
+	boolTrue     []string
+	boolFalse    []string
+}
+
+// CaseInsensitive makes the Decoder match map keys to field names ignoring their case,
+// using Unicode case-folding. A key that exactly matches a field name is always preferred.
+// When no exact match exists and more than one key folds to the same field name,
+// Unmarshal returns ErrAmbiguousKey. The same holds for the prefixes of the keys
+// passed to an inlined StringMapUnmarshaler.
+func (d *Decoder) CaseInsensitive() {
+	d.foldCase = true
+}
+
+// ResetMissing makes the Decoder set fields whose key is missing from the map
+// to their zero value, instead of leaving them untouched. This is useful when
+// unmarshaling into a value that is reused, so that stale values are not kept.
+func (d *Decoder) ResetMissing() {
+	d.resetMissing = true
+}
+
+// SetBoolSpellings sets additional strings, compared ignoring their case, that are accepted
+// as true and false booleans, such as "yes" and "no" or "on" and "off". Strings accepted by
+// strconv.ParseBool, which include "1" and "0", are always accepted.
+func (d *Decoder) SetBoolSpellings(truthy, falsy []string) {
+	d.boolTrue = append([]string(nil), truthy...)
+	d.boolFalse = append([]string(nil), falsy...)
+}
+
+// Unmarshal works like the Unmarshal function, but with the settings of d.
+func (d *Decoder) Unmarshal(data map[string]string, v interface{}) error {
 	if data == nil {
 		return errIs("map passed", ErrNilValue)
 	}
@@ -29,7 +85,58 @@ func Unmarshal(data map[string]string, v interface{}) error {
 	if err != nil {
 		return err
 	}
-	return unmarshalRecursive(data, "", val)
+	return d.unmarshal(mapSource(data), "", val)
+}
+
+// unmarshal unmarshals data into val, expecting prefix before all keys.
+func (d *Decoder) unmarshal(data source, prefix string, val reflect.Value) error {
+	state := decodeState{Decoder: d, data: data}
+	if d.foldCase {
+		state.folded = make(map[string][]string)
+		data.rangeKeys(func(k string) {
+			folded := foldKey(k)
+			state.folded[folded] = append(state.folded[folded], k)
+		})
+	}
+	return state.unmarshalRecursive(prefix, val)
+}
+
+// source holds the key-value pairs to be unmarshaled.
+type source interface {
+	get(key string) (string, bool)
+	rangeKeys(f func(key string))
+}
+
+// multiSource is a source with more than one value per key.
+// Unmarshaling from a multiSource fills slices with multiple values.
+type multiSource interface {
+	source
+	getAll(key string) ([]string, bool)
+}
+
+// mapSource is the source of Unmarshal.
+type mapSource map[string]string
+
+func (s mapSource) get(key string) (string, bool) {
+	str, ok := s[key]
+	return str, ok
+}
+
+func (s mapSource) rangeKeys(f func(key string)) {
+	for k := range s {
+		f(k)
+	}
+}
+
+// decodeState holds the state of a single Unmarshal call.
+type decodeState struct {
+	*Decoder
+	data source
+	// folded maps the case-folded version of each key in data to its original spellings.
+	// It is nil unless the Decoder is case-insensitive.
+	folded map[string][]string
+	// depth is the number of nested structs being unmarshaled.
+	depth int
 }
 
 func ptrValidValue(v interface{}) (reflect.Value, error) {
@@ -52,9 +159,14 @@ func ptrValidValue(v interface{}) (reflect.Value, error) {
 	return val, nil
 }
 
-func unmarshalRecursive(mp map[string]string, prefix string, stru reflect.Value) error {
+func (d *decodeState) unmarshalRecursive(prefix string, stru reflect.Value) error {
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > d.maxDepthOrDefault() {
+		return errIs(fmt.Sprintf("value at key prefix %q", prefix), ErrMaxDepth)
+	}
 	if ptr := stru.Addr(); ptr.Type().Implements(mapUnmarshalerType) {
-		return mapToStruct(mp, prefix, ptr)
+		return d.mapToStruct(prefix, ptr)
 	}
 	if stru.Kind() != reflect.Struct {
 		return errIs(stru.Type(), ErrNoCodec)
@@ -67,7 +179,7 @@ func unmarshalRecursive(mp map[string]string, prefix string, stru reflect.Value)
 			// TODO: In Go 1.17, use field.IsExported().
 			continue
 		}
-		tags := redmapTags(field.Tag)
+		tags := redmapTags(field.Tag, d.tagKeys)
 		if tags.ignored {
 			continue
 		}
@@ -75,95 +187,332 @@ func unmarshalRecursive(mp map[string]string, prefix string, stru reflect.Value)
 		if tags.name == "" {
 			tags.name = field.Name
 		}
-		tags.name = prefix + tags.name
+		tags.name = d.keyName(tags.name)
 
-		for value.Kind() == reflect.Ptr {
-			if value.IsNil() && !tags.omitempty {
-				if !value.CanSet() {
-					return fmt.Errorf("cannot set embedded pointer to unexported type %s", value.Elem().Type())
+		key := prefix + tags.name
+		if tags.inline {
+			if kind := value.Kind(); (kind == reflect.Ptr || kind == reflect.Interface) && d.hasNull {
+				str, ok, err := d.lookup(key)
+				if err != nil {
+					return err
+				}
+				if ok && d.isNull(str) {
+					if err := setZero(value); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if tags.typekey != "" {
+				err := d.unmarshalTyped(prefix, value, tags)
+				if err != nil {
+					return err
 				}
-				value.Set(reflect.New(value.Type().Elem()))
+				continue
+			}
+			if value.Kind() == reflect.Ptr && value.IsNil() && !d.anyKeyWithPrefix(d.inlinePrefix(prefix, tags)) {
+				continue
+			}
+			value, err := allocPointers(value)
+			if err != nil {
+				return err
+			}
+			err = d.unmarshalRecursive(d.inlinePrefix(prefix, tags), value)
+			if err != nil {
+				return err
 			}
-			value = value.Elem()
+			continue
 		}
 
-		if tags.inline {
-			err := unmarshalRecursive(mp, tags.name+inlineSep, value)
+		if _, ok := d.data.(multiSource); ok && d.isMultiValue(value, tags) {
+			strs, ok, err := d.lookupAll(key)
 			if err != nil {
 				return err
 			}
-		} else {
-			str, ok := mp[tags.name]
-			if !ok {
-				continue
+			if ok {
+				if value, err = allocPointers(value); err == nil {
+					err = d.stringsToSlice(strs, value, tags)
+				}
+			} else if d.resetMissing {
+				err = setZero(value)
 			}
-			err := stringToField(str, value, tags.omitempty)
 			if err != nil {
 				return err
 			}
+			continue
+		}
+
+		str, ok, err := d.lookup(key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if d.resetMissing {
+				if err := setZero(value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if value.Kind() == reflect.Ptr && d.isNull(str) {
+			if err := setZero(value); err != nil {
+				return err
+			}
+			continue
+		}
+		if !tags.json {
+			// The Serializer receives pointer fields as they are, so that it can leave them nil.
+			value, err = allocPointers(value)
+			if err != nil {
+				return err
+			}
+		}
+		err = d.stringToField(str, value, tags)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allocPointers dereferences value until it is not a pointer, allocating nil pointers on the way.
+func allocPointers(value reflect.Value) (reflect.Value, error) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if !value.CanSet() {
+				return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported type %s", value.Type().Elem())
+			}
+			value.Set(reflect.New(value.Type().Elem()))
 		}
+		value = value.Elem()
+	}
+	return value, nil
+}
+
+// setZero sets value to the zero value of its type.
+func setZero(value reflect.Value) error {
+	if !value.CanSet() {
+		return fmt.Errorf("cannot set embedded field of unexported type %s", value.Type())
 	}
+	value.Set(reflect.Zero(value.Type()))
 	return nil
 }
 
-func mapToStruct(mp map[string]string, prefix string, stru reflect.Value) error {
-	if prefix != "" {
+// lookup returns the value associated with key, honoring the case-sensitivity of the Decoder.
+func (d *decodeState) lookup(key string) (string, bool, error) {
+	key, ok, err := d.resolve(key)
+	if !ok {
+		return "", false, err
+	}
+	str, _ := d.data.get(key)
+	return str, true, nil
+}
+
+// lookupAll is like lookup, but it returns all the values associated with key.
+// d.data must be a multiSource.
+func (d *decodeState) lookupAll(key string) ([]string, bool, error) {
+	key, ok, err := d.resolve(key)
+	if !ok {
+		return nil, false, err
+	}
+	strs, _ := d.data.(multiSource).getAll(key)
+	return strs, true, nil
+}
+
+// resolve returns the key in d.data matching key, honoring the case-sensitivity of the Decoder.
+func (d *decodeState) resolve(key string) (string, bool, error) {
+	if _, ok := d.data.get(key); ok || d.folded == nil {
+		return key, ok, nil
+	}
+	switch keys := d.folded[foldKey(key)]; len(keys) {
+	case 0:
+		return "", false, nil
+	case 1:
+		return keys[0], true, nil
+	default:
+		return "", false, errIs(fmt.Sprintf("key %q", key), ErrAmbiguousKey)
+	}
+}
+
+// anyKeyWithPrefix reports whether any key starts with prefix.
+func (d *decodeState) anyKeyWithPrefix(prefix string) bool {
+	found := false
+	d.data.rangeKeys(func(k string) {
+		if !found {
+			_, found = d.trimPrefix(k, prefix)
+		}
+	})
+	return found
+}
+
+// trimPrefix returns key without prefix, and whether key starts with prefix,
+// honoring the case-sensitivity of the Decoder. When ignoring case, runes are
+// compared as foldKey does, thus the prefix of key may differ from prefix in length.
+func (d *decodeState) trimPrefix(key, prefix string) (string, bool) {
+	if d.folded == nil || strings.HasPrefix(key, prefix) {
+		return strings.TrimPrefix(key, prefix), strings.HasPrefix(key, prefix)
+	}
+	rest := key
+	for _, p := range prefix {
+		k, size := utf8.DecodeRuneInString(rest)
+		if size == 0 || foldRune(k) != foldRune(p) {
+			return "", false
+		}
+		rest = rest[size:]
+	}
+	return rest, true
+}
+
+// mapToStruct unmarshals the keys starting with prefix into stru,
+// which implements StringMapUnmarshaler, after removing the prefix.
+// When ignoring case, a key exactly matching prefix is preferred over the others
+// resulting in the same key, and ErrAmbiguousKey is returned if there's no such key.
+func (d *decodeState) mapToStruct(prefix string, stru reflect.Value) error {
+	mp, ok := d.data.(mapSource)
+	if prefix != "" || !ok {
 		// FIXME: Creating a submap is O(n). Can we think of a better algorithm?
-		subMP := make(map[string]string, len(mp))
-		for k, v := range mp {
-			if !strings.HasPrefix(k, prefix) {
-				continue
+		subMP := make(map[string]string)
+		// exact tells whether each key of subMP comes from a key exactly matching prefix.
+		exact := make(map[string]bool)
+		var err error
+		d.data.rangeKeys(func(k string) {
+			rest, ok := d.trimPrefix(k, prefix)
+			if !ok {
+				return
+			}
+			isExact := strings.HasPrefix(k, prefix)
+			if prevExact, dup := exact[rest]; dup {
+				if prevExact == isExact && err == nil {
+					err = errIs(fmt.Sprintf("key %q", prefix+rest), ErrAmbiguousKey)
+				}
+				if prevExact {
+					return
+				}
 			}
-			subMP[k[len(prefix):]] = v
+			subMP[rest], _ = d.data.get(k)
+			exact[rest] = isExact
+		})
+		if err != nil {
+			return err
 		}
 		mp = subMP
 	}
 	return stru.Interface().(StringMapUnmarshaler).UnmarshalStringMap(mp)
 }
 
-func stringToField(str string, field reflect.Value, omitempty bool) error {
+// isMultiValue reports whether field is a slice, or a pointer to one,
+// to be filled with multiple values, i.e. it doesn't have any custom decoding.
+func (d *decodeState) isMultiValue(field reflect.Value, tags structTags) bool {
+	typ := field.Type()
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Slice || tags.json || tags.list {
+		return false
+	}
+	if _, ok, _ := d.decoderFor(typ, tags); ok {
+		return false
+	}
+	return !reflect.PtrTo(typ).Implements(textUnmarshalerType)
+}
+
+// parseBool parses str with strconv.ParseBool, falling back to the spellings
+// set with SetBoolSpellings.
+func (d *Decoder) parseBool(str string) (bool, error) {
+	b, err := strconv.ParseBool(str)
+	if err == nil {
+		return b, nil
+	}
+	for _, t := range d.boolTrue {
+		if strings.EqualFold(str, t) {
+			return true, nil
+		}
+	}
+	for _, f := range d.boolFalse {
+		if strings.EqualFold(str, f) {
+			return false, nil
+		}
+	}
+	return false, err
+}
+
+// foldKey returns the case-folded version of key, used for case-insensitive lookups.
+func foldKey(key string) string {
+	return strings.ToLower(strings.ToUpper(key))
+}
+
+// foldRune returns the case-folded version of r, as foldKey does for each rune.
+func foldRune(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+func (d *decodeState) stringToField(str string, field reflect.Value, tags structTags) error {
+	if tags.json {
+		return d.serializerOrDefault().Unmarshal([]byte(str), field.Addr().Interface())
+	}
+	if tags.list {
+		return d.stringToSlice(str, field, tags)
+	}
+	decode, ok, err := d.decoderFor(field.Type(), tags)
+	if err != nil {
+		return err
+	}
+	if ok {
+		val, err := decode(str)
+		if err != nil {
+			return err
+		}
+		field.Set(val)
+		return nil
+	}
 	addr := field.Addr() // Unmarshaling always requires a pointer receiver.
 	if addr.Type().Implements(textUnmarshalerType) {
 		return addr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
 	}
 
-	var (
-		val reflect.Value
-		err error
-	)
+	base := 10
+	if kind := field.Kind(); reflect.Int <= kind && kind <= reflect.Uintptr { // Any integer kind.
+		f, err := d.numberFormat(tags)
+		if err != nil {
+			return err
+		}
+		base = f.parseBase()
+	}
+
+	var val reflect.Value
 	switch field.Kind() {
 	case reflect.Bool:
-		v, e := strconv.ParseBool(str)
+		v, e := d.parseBool(str)
 		val, err = reflect.ValueOf(v), e
 	case reflect.Int:
-		v, e := strconv.ParseInt(str, 10, 0)
+		v, e := strconv.ParseInt(str, base, 0)
 		val, err = reflect.ValueOf(int(v)), e
 	case reflect.Int8:
-		v, e := strconv.ParseInt(str, 10, 8)
+		v, e := strconv.ParseInt(str, base, 8)
 		val, err = reflect.ValueOf(int8(v)), e
 	case reflect.Int16:
-		v, e := strconv.ParseInt(str, 10, 16)
+		v, e := strconv.ParseInt(str, base, 16)
 		val, err = reflect.ValueOf(int16(v)), e
 	case reflect.Int32:
-		v, e := strconv.ParseInt(str, 10, 32)
+		v, e := strconv.ParseInt(str, base, 32)
 		val, err = reflect.ValueOf(int32(v)), e
 	case reflect.Int64:
-		v, e := strconv.ParseInt(str, 10, 64)
+		v, e := strconv.ParseInt(str, base, 64)
 		val, err = reflect.ValueOf(v), e
 	case reflect.Uint:
-		v, e := strconv.ParseUint(str, 10, 0)
+		v, e := strconv.ParseUint(str, base, 0)
 		val, err = reflect.ValueOf(uint(v)), e
 	case reflect.Uint8:
-		v, e := strconv.ParseUint(str, 10, 8)
+		v, e := strconv.ParseUint(str, base, 8)
 		val, err = reflect.ValueOf(uint8(v)), e
 	case reflect.Uint16:
-		v, e := strconv.ParseUint(str, 10, 16)
+		v, e := strconv.ParseUint(str, base, 16)
 		val, err = reflect.ValueOf(uint16(v)), e
 	case reflect.Uint32:
-		v, e := strconv.ParseUint(str, 10, 32)
+		v, e := strconv.ParseUint(str, base, 32)
 		val, err = reflect.ValueOf(uint32(v)), e
 	case reflect.Uint64:
-		v, e := strconv.ParseUint(str, 10, 64)
+		v, e := strconv.ParseUint(str, base, 64)
 		val, err = reflect.ValueOf(v), e
 	case reflect.Float32:
 		v, e := strconv.ParseFloat(str, 32)
@@ -185,10 +534,8 @@ func stringToField(str string, field reflect.Value, omitempty bool) error {
 	if err != nil {
 		return err
 	}
-	if omitempty && val.IsZero() {
-		return nil
-	}
-	field.Set(val)
+	// Convert to support named types, such as enums, whose underlying type is a built-in.
+	field.Set(val.Convert(field.Type()))
 	return nil
 }
 
@@ -332,3 +332,345 @@ func TestUnmarshalNilField(t *testing.T) {
 		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", mp, expected, out)
 	}
 }
+
+func TestUnmarshalCaseInsensitive(t *testing.T) {
+	type stru struct {
+		Name  string
+		Inner stubMapUnmarshaler `redmap:",inline"`
+		K     stubMapUnmarshaler `redmap:"k,inline"`
+	}
+	tests := []struct {
+		In  map[string]string
+		Out stru
+	}{
+		{In: map[string]string{"name": "lower"}, Out: stru{Name: "lower"}},
+		{In: map[string]string{"NAME": "upper"}, Out: stru{Name: "upper"}},
+		{In: map[string]string{"Name": "exact", "name": "lower", "NAME": "upper"}, Out: stru{Name: "exact"}},
+		{In: map[string]string{"inner.Field1": "value1", "INNER.Field2": "value2"}, Out: stru{Inner: stubMapUnmarshaler{Field1: "value1", Field2: "value2"}}},
+		{In: map[string]string{"Inner.Field1": "exact", "inner.Field1": "lower"}, Out: stru{Inner: stubMapUnmarshaler{Field1: "exact"}}},
+		// The Kelvin sign is longer than "K" but folds to it.
+		{In: map[string]string{"\u212A.Field1": "kelvin"}, Out: stru{K: stubMapUnmarshaler{Field1: "kelvin"}}},
+	}
+	var dec redmap.Decoder
+	dec.CaseInsensitive()
+	for _, test := range tests {
+		var out stru
+		err := dec.Unmarshal(test.In, &out)
+		if err != nil {
+			t.Fatalf("Unmarshal returned unexpected error %q", err)
+		}
+		if !reflect.DeepEqual(out, test.Out) {
+			t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", test.In, test.Out, out)
+		}
+	}
+}
+
+func TestUnmarshalCaseInsensitiveAmbiguous(t *testing.T) {
+	var (
+		dec redmap.Decoder
+		out struct{ Name string }
+	)
+	dec.CaseInsensitive()
+	err := dec.Unmarshal(map[string]string{"name": "lower", "NAME": "upper"}, &out)
+	if !errors.Is(err, redmap.ErrAmbiguousKey) {
+		t.Fatalf("Unmarshal returned %q but should have returned %q", err, redmap.ErrAmbiguousKey)
+	}
+
+	var inline struct {
+		Inner stubMapUnmarshaler `redmap:",inline"`
+	}
+	err = dec.Unmarshal(map[string]string{"inner.Field1": "lower", "INNER.Field1": "upper"}, &inline)
+	if !errors.Is(err, redmap.ErrAmbiguousKey) {
+		t.Fatalf("Unmarshal returned %q but should have returned %q", err, redmap.ErrAmbiguousKey)
+	}
+}
+
+func TestUnmarshalTagKeys(t *testing.T) {
+	type stru struct {
+		RedmapOnly string `redmap:"redmap"`
+		JSONOnly   string `json:"json,string"`
+		Both       string `redmap:"both" json:"unused"`
+		Ignored    string `json:"-"`
+	}
+	expected := stru{RedmapOnly: "redmap", JSONOnly: "json", Both: "both"}
+	mp := map[string]string{
+		"redmap":  "redmap",
+		"json":    "json",
+		"both":    "both",
+		"unused":  "should be ignored",
+		"Ignored": "should be ignored",
+	}
+	var (
+		dec redmap.Decoder
+		out stru
+	)
+	dec.SetTagKeys("redmap", "json")
+	err := dec.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", mp, expected, out)
+	}
+}
+
+func TestUnmarshalInlineSeparator(t *testing.T) {
+	type Inner = struct {
+		String string
+	}
+	type stru struct {
+		Colon    Inner              `redmap:"colon,inline,sep=:"`
+		Comma    Inner              `redmap:"comma,inline,sep=,"`
+		Empty    Inner              `redmap:"empty,inline,sep="`
+		NoPrefix Inner              `redmap:",inline,noprefix"`
+		Map      stubMapUnmarshaler `redmap:"map,inline,sep=_"`
+	}
+	expected := stru{
+		Colon:    Inner{String: "colon"},
+		Comma:    Inner{String: "comma"},
+		Empty:    Inner{String: "empty"},
+		NoPrefix: Inner{String: "noprefix"},
+		Map:      stubMapUnmarshaler{Field1: "value1", Field2: "value2"},
+	}
+	mp := map[string]string{
+		"colon:String": "colon",
+		"comma,String": "comma",
+		"emptyString":  "empty",
+		"String":       "noprefix",
+		"map_Field1":   "value1",
+		"map_Field2":   "value2",
+	}
+	var out stru
+	err := redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", mp, expected, out)
+	}
+}
+
+func TestUnmarshalNullField(t *testing.T) {
+	type Inner = struct {
+		String string
+	}
+	type stru struct {
+		Nil     *int
+		Zero    *int
+		Missing *int
+		Inline  *Inner `redmap:",inline"`
+	}
+	one := 1
+	out := stru{Nil: &one, Inline: &Inner{String: "should be nil"}}
+	mp := map[string]string{"Nil": "", "Zero": "0", "Inline": ""}
+	var dec redmap.Decoder
+	dec.SetNull("")
+	err := dec.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if out.Nil != nil || out.Inline != nil {
+		t.Fatalf("Unmarshal did not set null fields to nil: %+v", out)
+	}
+	if out.Zero == nil || *out.Zero != 0 {
+		t.Fatalf("Unmarshal did not set non-null field to zero: %+v", out)
+	}
+}
+
+func TestUnmarshalExplicitZero(t *testing.T) {
+	type stru struct {
+		OmitEmpty int    `redmap:",omitempty"`
+		OmitZero  string `redmap:",omitzero"`
+		Pointer   *int   `redmap:",omitempty"`
+	}
+	zero := 0
+	expected := stru{Pointer: &zero}
+	mp := map[string]string{"OmitEmpty": "0", "OmitZero": "", "Pointer": "0"}
+	out := stru{OmitEmpty: 1, OmitZero: "stale"}
+	err := redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", mp, expected, out)
+	}
+}
+
+func TestUnmarshalOmitEmptyInline(t *testing.T) {
+	type Inner struct{ Name string }
+	type stru struct {
+		DB    *Inner `redmap:"db,inline,omitempty"`
+		Cache *Inner `redmap:"cache,inline,omitempty"`
+	}
+	in := stru{DB: &Inner{Name: "x"}}
+	mp, err := redmap.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	var out stru
+	err = redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", mp, in, out)
+	}
+}
+
+func TestUnmarshalMissing(t *testing.T) {
+	type Inner = struct {
+		String string
+	}
+	type stru struct {
+		Present string
+		Missing string
+		Pointer *int
+		Inline  Inner `redmap:",inline"`
+	}
+	one := 1
+	stale := stru{Present: "stale", Missing: "stale", Pointer: &one, Inline: Inner{String: "stale"}}
+	mp := map[string]string{"Present": "fresh"}
+	tests := []struct {
+		Reset bool
+		Out   stru
+	}{
+		{Reset: false, Out: stru{Present: "fresh", Missing: "stale", Pointer: &one, Inline: Inner{String: "stale"}}},
+		{Reset: true, Out: stru{Present: "fresh"}},
+	}
+	for _, test := range tests {
+		var dec redmap.Decoder
+		if test.Reset {
+			dec.ResetMissing()
+		}
+		out := stale
+		err := dec.Unmarshal(mp, &out)
+		if err != nil {
+			t.Fatalf("Unmarshal returned unexpected error %q", err)
+		}
+		if !reflect.DeepEqual(out, test.Out) {
+			t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", mp, test.Out, out)
+		}
+	}
+}
+
+// stubEnum is an enum that implements fmt.Stringer but not encoding.TextUnmarshaler.
+type stubEnum int
+
+const (
+	stubEnumZero stubEnum = iota
+	stubEnumOne
+)
+
+func (s stubEnum) String() string {
+	if s == stubEnumOne {
+		return "one"
+	}
+	return "zero"
+}
+
+func parseStubEnum(str string) (stubEnum, error) {
+	switch str {
+	case "zero":
+		return stubEnumZero, nil
+	case "one":
+		return stubEnumOne, nil
+	}
+	return 0, fmt.Errorf("invalid stubEnum %q", str)
+}
+
+func init() {
+	redmap.RegisterParser(parseStubEnum)
+}
+
+func TestUnmarshalRegisteredParser(t *testing.T) {
+	type stru struct {
+		Enum    stubEnum
+		Pointer *stubEnum
+	}
+	one := stubEnumOne
+	expected := stru{Enum: stubEnumOne, Pointer: &one}
+	mp, err := redmap.Marshal(expected)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %q", err)
+	}
+	var out stru
+	err = redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", mp, expected, out)
+	}
+	err = redmap.Unmarshal(map[string]string{"Enum": "two"}, &out)
+	if err == nil {
+		t.Fatal("Unmarshal did not return the parser's error")
+	}
+}
+
+func TestUnmarshalNamedScalar(t *testing.T) {
+	type named int
+	expected := struct{ V named }{100}
+	mp := map[string]string{"V": "100"}
+	var out struct{ V named }
+	err := redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", mp, expected, out)
+	}
+}
+
+func TestUnmarshalBoolSpellings(t *testing.T) {
+	tests := []struct {
+		In  string
+		Out bool
+	}{
+		{In: "1", Out: true},
+		{In: "false", Out: false},
+		{In: "yes", Out: true},
+		{In: "No", Out: false},
+		{In: "ON", Out: true},
+		{In: "off", Out: false},
+	}
+	var dec redmap.Decoder
+	dec.SetBoolSpellings([]string{"yes", "on"}, []string{"no", "off"})
+	for _, test := range tests {
+		out := struct{ V bool }{!test.Out}
+		err := dec.Unmarshal(map[string]string{"V": test.In}, &out)
+		if err != nil {
+			t.Fatalf("Unmarshal returned unexpected error %q", err)
+		}
+		if out.V != test.Out {
+			t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", test.In, test.Out, out.V)
+		}
+	}
+	var out struct{ V bool }
+	if err := dec.Unmarshal(map[string]string{"V": "maybe"}, &out); err == nil {
+		t.Fatal("Unmarshal of an unknown boolean spelling did not return error")
+	}
+}
+
+func TestUnmarshalRecursiveType(t *testing.T) {
+	type node struct {
+		Value int
+		Next  *node `redmap:",inline"`
+	}
+	mp := map[string]string{"Value": "1", "Next.Value": "2", "Next.Next.Value": "3"}
+	expected := node{Value: 1, Next: &node{Value: 2, Next: &node{Value: 3}}}
+	var out node
+	err := redmap.Unmarshal(mp, &out)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Unmarshal's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", mp, expected, out)
+	}
+
+	var dec redmap.Decoder
+	dec.SetMaxDepth(2)
+	err = dec.Unmarshal(mp, &node{})
+	if !errors.Is(err, redmap.ErrMaxDepth) {
+		t.Fatalf("Unmarshal returned %q but %q was expected", err, redmap.ErrMaxDepth)
+	}
+}
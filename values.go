@@ -0,0 +1,81 @@
+package redmap
+
+import "net/url"
+
+// MarshalValues returns the url.Values representation of v, suitable for query strings and
+// application/x-www-form-urlencoded bodies. It follows the same rules as Marshal, except that
+// slice fields without a custom encoding are stored as multiple values of the same key.
+//
+// MarshalValues is equivalent to calling MarshalValues on a zero Encoder.
+func MarshalValues(v interface{}) (url.Values, error) {
+	var enc Encoder
+	return enc.MarshalValues(v)
+}
+
+// MarshalValues works like the MarshalValues function, but with the settings of e.
+func (e *Encoder) MarshalValues(v interface{}) (url.Values, error) {
+	val, err := validValue(v)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(url.Values)
+	return ret, e.marshal(valuesSink(ret), "", val)
+}
+
+// UnmarshalValues sets v's fields according to the values contained by data.
+// It follows the same rules as Unmarshal, except that slice fields without
+// a custom decoding are filled with all the values of their key.
+// Other fields take the first value of their key.
+//
+// UnmarshalValues is equivalent to calling UnmarshalValues on a zero Decoder.
+func UnmarshalValues(data url.Values, v interface{}) error {
+	var dec Decoder
+	return dec.UnmarshalValues(data, v)
+}
+
+// UnmarshalValues works like the UnmarshalValues function, but with the settings of d.
+func (d *Decoder) UnmarshalValues(data url.Values, v interface{}) error {
+	if data == nil {
+		return errIs("values passed", ErrNilValue)
+	}
+	val, err := ptrValidValue(v)
+	if err != nil {
+		return err
+	}
+	return d.unmarshal(valuesSource(data), "", val)
+}
+
+// valuesSink is the sink of MarshalValues.
+type valuesSink url.Values
+
+func (s valuesSink) set(key, value string) { url.Values(s).Set(key, value) }
+
+func (s valuesSink) add(key string, values []string) {
+	if len(values) > 0 {
+		s[key] = values
+	}
+}
+
+// valuesSource is the source of UnmarshalValues.
+type valuesSource url.Values
+
+func (s valuesSource) get(key string) (string, bool) {
+	vals, ok := s[key]
+	if !ok || len(vals) == 0 {
+		return "", false
+	}
+	return vals[0], true
+}
+
+func (s valuesSource) getAll(key string) ([]string, bool) {
+	vals, ok := s[key]
+	return vals, ok && len(vals) > 0
+}
+
+func (s valuesSource) rangeKeys(f func(key string)) {
+	for k, vals := range s {
+		if len(vals) > 0 {
+			f(k)
+		}
+	}
+}
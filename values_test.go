@@ -0,0 +1,97 @@
+package redmap_test
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/livingsilver94/redmap"
+)
+
+type valuesFilter struct {
+	Query  string   `redmap:"q"`
+	Tags   []string `redmap:"tag"`
+	IDs    []int    `redmap:"id"`
+	Joined []string `redmap:"joined,list"`
+	Page   struct {
+		Number int `redmap:"number"`
+		Size   int `redmap:"size"`
+	} `redmap:"page,inline"`
+	Time stubTextMarshaler `redmap:"time"`
+}
+
+func TestMarshalValues(t *testing.T) {
+	in := valuesFilter{Query: "redis", Tags: []string{"a", "b"}, IDs: []int{1, 2, 3}, Joined: []string{"x", "y"}}
+	in.Page.Number = 2
+	in.Page.Size = 50
+	expected := url.Values{
+		"q":           {"redis"},
+		"tag":         {"a", "b"},
+		"id":          {"1", "2", "3"},
+		"joined":      {"x,y"},
+		"page.number": {"2"},
+		"page.size":   {"50"},
+		"time":        {textMarshalerOut},
+	}
+	out, err := redmap.MarshalValues(in)
+	if err != nil {
+		t.Fatalf("MarshalValues returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("MarshalValues's output doesn't match the expected value\n\tExpected: %v\n\tOut: %v", expected, out)
+	}
+}
+
+func TestUnmarshalValues(t *testing.T) {
+	query, err := url.ParseQuery("q=redis&tag=a&tag=b&id=1&id=2&id=3&joined=x,y&page.number=2&page.size=50&page.size=100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := valuesFilter{Query: "redis", Tags: []string{"a", "b"}, IDs: []int{1, 2, 3}, Joined: []string{"x", "y"}}
+	expected.Page.Number = 2
+	expected.Page.Size = 50
+	var out valuesFilter
+	err = redmap.UnmarshalValues(query, &out)
+	if err != nil {
+		t.Fatalf("UnmarshalValues returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("UnmarshalValues's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", query, expected, out)
+	}
+}
+
+func TestValuesPointerToSlice(t *testing.T) {
+	type stru struct {
+		Tags *[]string `redmap:"tag"`
+	}
+	out, err := redmap.MarshalValues(stru{})
+	if err != nil {
+		t.Fatalf("MarshalValues returned unexpected error %q", err)
+	}
+	if len(out) != 0 {
+		t.Fatalf("MarshalValues returned %v for a nil pointer to a slice", out)
+	}
+
+	tags := []string{"a", "b"}
+	in := stru{Tags: &tags}
+	out, err = redmap.MarshalValues(in)
+	if err != nil {
+		t.Fatalf("MarshalValues returned unexpected error %q", err)
+	}
+	var back stru
+	err = redmap.UnmarshalValues(out, &back)
+	if err != nil {
+		t.Fatalf("UnmarshalValues returned unexpected error %q", err)
+	}
+	if !reflect.DeepEqual(back, in) {
+		t.Fatalf("UnmarshalValues's output doesn't match the expected value\n\tIn: %v\n\tExpected: %v\n\tOut: %v", out, in, back)
+	}
+}
+
+func TestUnmarshalValuesNil(t *testing.T) {
+	err := redmap.UnmarshalValues(nil, &valuesFilter{})
+	if !errors.Is(err, redmap.ErrNilValue) {
+		t.Fatal("UnmarshalValues() with nil values did not return the specific error")
+	}
+}